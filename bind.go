@@ -0,0 +1,217 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/fogfish/golem/hseq"
+	"github.com/fogfish/gouldian/v2/internal/optics"
+)
+
+/*
+Binding is a collection of endpoints derived from struct tags of the request
+type. It is an alternative to OpticsN, the lenses are declared by tags:
+
+	type Request struct {
+		ID     string `path:"id"`
+		Limit  int    `query:"limit,optional"`
+		Tenant string `header:"X-Tenant"`
+		User   string `jwt:"sub"`
+		Item   Item   `body:"json"`
+	}
+
+	req := µ.Bind[Request]()
+	µ.PUT(
+		req.URI("/items/:id"),
+		req.Endpoint(),
+		µ.FMap(func(ctx *µ.Context, req *Request) error { ... }),
+	)
+
+The tag `query` binds query param, `header` binds HTTP header, `jwt` binds
+claim of JWT and `body` decodes HTTP request body using json or form codec.
+The option `optional` turns the combinator into its Maybe variant. The tag
+`path` names the lens of path segment, it is used by URI or Path. The tag
+`query:"*"` binds all query params to the map or struct (see Params).
+
+Nested structs without tags group fields, the field Key.Tenant is bound by
+tags of Tenant field.
+*/
+type Binding struct {
+	path map[string]Lens
+	seq  Endpoints
+}
+
+// Bind derives endpoints from struct tags of type T, see Binding
+func Bind[T any]() Binding {
+	binding := Binding{path: map[string]Lens{}}
+
+	for _, t := range bindSeq(hseq.New[T]()) {
+		if name, ok := t.Tag.Lookup("path"); ok {
+			binding.path[name] = bindLens(t, t.Tag.Get("content"))
+		}
+
+		if name, optional, ok := bindTag(t.Tag, "query"); ok {
+			content := t.Tag.Get("content")
+			switch {
+			case name == "*":
+				if content == "" {
+					content = "form"
+				}
+				binding.seq = append(binding.seq, Params(bindLens(t, content)))
+			case optional:
+				binding.seq = append(binding.seq, ParamMaybe(name, bindLens(t, content)))
+			default:
+				binding.seq = append(binding.seq, Param(name, bindLens(t, content)))
+			}
+		}
+
+		if name, optional, ok := bindTag(t.Tag, "header"); ok {
+			lens := bindLens(t, t.Tag.Get("content"))
+			if optional {
+				binding.seq = append(binding.seq, HeaderMaybe(name, lens))
+			} else {
+				binding.seq = append(binding.seq, Header(name, lens))
+			}
+		}
+
+		if name, optional, ok := bindTag(t.Tag, "jwt"); ok {
+			lens := bindLens(t, t.Tag.Get("content"))
+			claim := func(token Token) string { return token[name] }
+			if optional {
+				binding.seq = append(binding.seq, JWTMaybe(claim, lens))
+			} else {
+				binding.seq = append(binding.seq, JWT(claim, lens))
+			}
+		}
+
+		if content, ok := t.Tag.Lookup("body"); ok {
+			binding.seq = append(binding.seq, Body(bindLens(t, content)))
+		}
+	}
+
+	return binding
+}
+
+// bindSeq unfolds nested structs without tags, the name of nested field
+// is the path to the field, e.g. Key.Tenant
+func bindSeq[T any](seq hseq.Seq[T]) hseq.Seq[T] {
+	nseq := make(hseq.Seq[T], 0, len(seq))
+
+	for _, t := range seq {
+		if t.StructField.Type.Kind() != reflect.Struct || hasBindTag(t.Tag) {
+			nseq = append(nseq, t)
+			continue
+		}
+
+		cat := t.StructField.Type
+		nested := make(hseq.Seq[T], 0, cat.NumField())
+		for i := 0; i < cat.NumField(); i++ {
+			if f := cat.Field(i); f.IsExported() {
+				field := fieldOf[T](cat, f.Name, t.RootOffs+t.Offset)
+				field.StructField.Name = t.StructField.Name + "." + f.Name
+				nested = append(nested, field)
+			}
+		}
+		nseq = append(nseq, bindSeq(nested)...)
+	}
+
+	return nseq
+}
+
+func hasBindTag(tag reflect.StructTag) bool {
+	for _, key := range []string{"path", "query", "header", "jwt", "body"} {
+		if _, ok := tag.Lookup(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// bindTag parses tag `name,optional`
+func bindTag(tag reflect.StructTag, key string) (string, bool, bool) {
+	val, ok := tag.Lookup(key)
+	if !ok {
+		return "", false, false
+	}
+
+	name, opt, _ := strings.Cut(val, ",")
+	return name, opt == "optional", true
+}
+
+func bindLens[T any](t hseq.Type[T], content string) Lens {
+	return Lens{
+		Lens:   optics.NewLensReflect(t, content),
+		target: reflect.TypeOf(new(T)).Elem(),
+		field:  t.StructField,
+	}
+}
+
+/*
+Path is an endpoint to match a single URL segment of HTTP request to the
+field tagged by `path:"name"`. It panics if the name is not bound.
+
+	µ.URI(µ.Path("items"), req.Path("id", µ.IsInt()))
+*/
+func (binding Binding) Path(name string, is ...Constraint) Segment {
+	lens, ok := binding.path[name]
+	if !ok {
+		panic("path segment " + name + " is not bound")
+	}
+
+	return Path(lens, is...)
+}
+
+/*
+URI is an endpoint to match URL of HTTP request to the path template.
+The template is a sequence of literals and placeholders `:name`, the last
+segment might be `*name` that matches the remaining path.
+
+	req.URI("/items/:id")
+*/
+func (binding Binding) URI(path string) Routable {
+	seq := strings.Split(strings.Trim(path, "/"), "/")
+	segments := make([]Segment, 0, len(seq))
+
+	for _, segment := range seq {
+		switch {
+		case segment == "":
+			continue
+		case strings.HasPrefix(segment, ":"):
+			segments = append(segments, binding.Path(segment[1:]))
+		case strings.HasPrefix(segment, "*"):
+			lens, ok := binding.path[segment[1:]]
+			if !ok {
+				panic("path segment " + segment[1:] + " is not bound")
+			}
+			segments = append(segments, PathAll(lens))
+		default:
+			segments = append(segments, Path(segment))
+		}
+	}
+
+	return URI(segments...)
+}
+
+// Endpoint matches query params, headers, JWT claims and body of
+// HTTP request to fields of the request type
+func (binding Binding) Endpoint() Endpoint {
+	return declare(declarationsOf(binding.seq), binding.seq.Join)
+}
@@ -0,0 +1,241 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+type bindItem struct {
+	Title string `json:"title"`
+}
+
+type bindT struct {
+	ID     string   `path:"id"`
+	Limit  int      `query:"limit"`
+	Tags   []string `query:"tag,optional"`
+	Tenant string   `header:"X-Tenant"`
+	Trace  *string  `header:"X-Trace,optional"`
+	User   string   `jwt:"sub"`
+	Item   bindItem `body:"json"`
+}
+
+func TestBind(t *testing.T) {
+	var val bindT
+	req := µ.Bind[bindT]()
+	foo := mock.Endpoint(
+		µ.PUT(
+			req.URI("/items/:id"),
+			req.Endpoint(),
+			µ.FMap(func(ctx *µ.Context, t *bindT) error {
+				val = *t
+				return nil
+			}),
+		),
+	)
+
+	t.Run("Match", func(t *testing.T) {
+		val = bindT{}
+		err := foo(
+			mock.Input(
+				mock.Method("PUT"),
+				mock.URL("/items/a1?limit=10&tag=x&tag=y"),
+				mock.Header("X-Tenant", "acme"),
+				mock.JWT(µ.Token{"sub": "joe"}),
+				mock.JSON(bindItem{Title: "foo"}),
+			),
+		)
+
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(val.ID, "a1"),
+			it.Equal(val.Limit, 10),
+			it.Seq(val.Tags).Equal("x", "y"),
+			it.Equal(val.Tenant, "acme"),
+			it.True(val.Trace == nil),
+			it.Equal(val.User, "joe"),
+			it.Equal(val.Item.Title, "foo"),
+		)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		for _, tt := range []struct {
+			url, tenant string
+			expect      error
+		}{
+			{"/items/a1", "acme", µ.NoMatch{Status: http.StatusBadRequest, Source: "param", Key: "limit", Reason: "is missing"}},
+			{"/items/a1?limit=abc", "acme", µ.NoMatch{Status: http.StatusBadRequest, Source: "param", Key: "limit", Reason: "is not valid"}},
+			{"/items/a1?limit=10", "", µ.NoMatch{Status: http.StatusBadRequest, Source: "header", Key: "X-Tenant", Reason: "is missing"}},
+		} {
+			err := foo(
+				mock.Input(
+					mock.Method("PUT"),
+					mock.URL(tt.url),
+					mock.Header("X-Tenant", tt.tenant),
+					mock.JWT(µ.Token{"sub": "joe"}),
+					mock.JSON(bindItem{Title: "foo"}),
+				),
+			)
+
+			it.Then(t).Should(
+				it.Equal(err, tt.expect),
+			)
+		}
+	})
+}
+
+func TestBindPath(t *testing.T) {
+	type T struct {
+		ID   int    `path:"id"`
+		Path string `path:"path"`
+	}
+
+	var val T
+	req := µ.Bind[T]()
+	foo := mock.Endpoint(
+		µ.GET(
+			µ.URI(µ.Path("items"), req.Path("id", µ.IsInt()), µ.PathAll(µ.Optics1[T, string]("Path"))),
+			req.Endpoint(),
+			µ.FMap(func(ctx *µ.Context, t *T) error {
+				val = *t
+				return nil
+			}),
+		),
+	)
+	bar := mock.Endpoint(
+		µ.GET(
+			req.URI("files/*path"),
+			µ.FMap(func(ctx *µ.Context, t *T) error {
+				val = *t
+				return nil
+			}),
+		),
+	)
+
+	it.Then(t).Should(
+		it.Nil(foo(mock.Input(mock.URL("/items/10/a/b")))),
+		it.Equal(val.ID, 10),
+		it.Equal(val.Path, "a/b"),
+		it.Nil(bar(mock.Input(mock.URL("/files/c/d")))),
+		it.Equal(val.Path, "c/d"),
+		it.Fail(func() { req.Path("unknown") }),
+		it.Fail(func() { req.URI("/items/:unknown") }),
+	)
+}
+
+func TestBindNested(t *testing.T) {
+	type Key struct {
+		Tenant string `path:"tenant"`
+		ID     int    `path:"id"`
+	}
+	type T struct {
+		Key    Key
+		Limit  int               `query:"limit,optional" default:"25"`
+		Params map[string]string `query:"*"`
+		Item   bindItem          `body:"json"`
+	}
+
+	var (
+		val   T
+		bound bool
+	)
+	req := µ.Bind[T]()
+	foo := mock.Endpoint(
+		µ.PUT(
+			req.URI("/:tenant/items/:id"),
+			req.Endpoint(),
+			µ.FMap(func(ctx *µ.Context, t *T) error {
+				val = *t
+				bound = ctx.IsBound("Key.Tenant")
+				return nil
+			}),
+		),
+	)
+
+	err := foo(
+		mock.Input(
+			mock.Method("PUT"),
+			mock.URL("/acme/items/10?sort=asc"),
+			mock.JSON(bindItem{Title: "foo"}),
+		),
+	)
+
+	it.Then(t).Should(
+		it.Nil(err),
+		it.True(bound),
+		it.Equal(val.Key.Tenant, "acme"),
+		it.Equal(val.Key.ID, 10),
+		it.Equal(val.Limit, 25),
+		it.Equiv(val.Params, map[string]string{"sort": "asc"}),
+		it.Equal(val.Item.Title, "foo"),
+	)
+}
+
+// bindCounted counts calls of UnmarshalJSON
+type bindCounted struct{ Title string }
+
+var bindUnmarshal int
+
+func (v *bindCounted) UnmarshalJSON(b []byte) error {
+	bindUnmarshal++
+	var item bindItem
+	err := json.Unmarshal(b, &item)
+	v.Title = item.Title
+	return err
+}
+
+func TestBindBodyDecodedOnce(t *testing.T) {
+	type T struct {
+		Item bindCounted `body:"json"`
+	}
+
+	var val T
+	req := µ.Bind[T]()
+	foo := mock.Endpoint(
+		µ.PUT(
+			µ.URI(µ.Path("items")),
+			req.Endpoint(),
+			µ.FMap(func(ctx *µ.Context, t *T) error {
+				val = *t
+				return nil
+			}),
+		),
+	)
+
+	bindUnmarshal = 0
+	err := foo(
+		mock.Input(
+			mock.Method("PUT"),
+			mock.URL("/items"),
+			mock.JSON(bindItem{Title: "foo"}),
+		),
+	)
+
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(val.Item.Title, "foo"),
+		it.Equal(bindUnmarshal, 1),
+	)
+}
@@ -0,0 +1,141 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Bulkhead limits the number of in-flight executions of endpoints so that
+slow dependencies of one route do not exhaust the service. Requests over
+the limit are queued up to the maximum wait, the saturated bulkhead answers
+503 Service Unavailable with Retry-After header.
+
+	bulkhead := µ.NewBulkhead(10, 100, time.Second)
+
+	µ.GET(
+		µ.URI(µ.Path("users")),
+		bulkhead.Wrap(µ.FMap( ... )),
+	)
+
+The slot is taken once the route has matched, the bulkhead wraps the
+handler rather than the route. Wrap handlers of each route with the same
+bulkhead to share it by the group.
+*/
+type Bulkhead struct {
+	slots    chan struct{}
+	queue    int64
+	wait     time.Duration
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+/*
+NewBulkhead creates bulkhead with limit of in-flight executions, the queue
+of requests waiting for the execution and the maximum wait in the queue.
+The bulkhead fails fast if queue is 0. The request waits until it is
+cancelled if wait is 0.
+*/
+func NewBulkhead(limit, queue int, wait time.Duration) *Bulkhead {
+	return &Bulkhead{
+		slots: make(chan struct{}, limit),
+		queue: int64(queue),
+		wait:  wait,
+	}
+}
+
+// BulkheadStats is the snapshot of bulkhead gauges
+type BulkheadStats struct {
+	InFlight    int // number of in-flight executions
+	MaxInFlight int // limit of in-flight executions
+	Queued      int // number of requests waiting for the execution
+	MaxQueued   int // limit of requests waiting for the execution
+	Rejected    int // number of requests rejected since the bulkhead is created
+}
+
+// Stats returns current gauges of the bulkhead
+func (b *Bulkhead) Stats() BulkheadStats {
+	return BulkheadStats{
+		InFlight:    len(b.slots),
+		MaxInFlight: cap(b.slots),
+		Queued:      int(b.queued.Load()),
+		MaxQueued:   int(b.queue),
+		Rejected:    int(b.rejected.Load()),
+	}
+}
+
+// Wrap the endpoint with bulkhead
+func (b *Bulkhead) Wrap(endpoint Endpoint) Endpoint {
+	return func(ctx *Context) error {
+		if !b.acquire(ctx) {
+			b.rejected.Add(1)
+
+			out := NewOutput(http.StatusServiceUnavailable)
+			out.SetHeader("Retry-After", seconds(max(b.wait, time.Second)))
+			out.SetIssue(fmt.Errorf("bulkhead %d is saturated", cap(b.slots)))
+			return out
+		}
+		defer b.release()
+
+		return endpoint(ctx)
+	}
+}
+
+func (b *Bulkhead) acquire(ctx *Context) bool {
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if b.queued.Add(1) > b.queue {
+		b.queued.Add(-1)
+		return false
+	}
+	defer b.queued.Add(-1)
+
+	var timeout <-chan time.Time
+	if b.wait > 0 {
+		timer := time.NewTimer(b.wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var done <-chan struct{}
+	if ctx.Context != nil {
+		done = ctx.Done()
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	case <-timeout:
+		return false
+	case <-done:
+		return false
+	}
+}
+
+func (b *Bulkhead) release() {
+	<-b.slots
+}
@@ -0,0 +1,155 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	ø "github.com/fogfish/gouldian/v2/output"
+	"github.com/fogfish/it/v2"
+)
+
+// blocking endpoint, it signals once it is in-flight and waits for release
+func blocking() (µ.Endpoint, chan struct{}, chan struct{}) {
+	started, release := make(chan struct{}), make(chan struct{})
+	return func(ctx *µ.Context) error {
+		started <- struct{}{}
+		<-release
+		return ø.Status.OK()
+	}, started, release
+}
+
+func TestBulkheadFailFast(t *testing.T) {
+	bulkhead := µ.NewBulkhead(1, 0, 0)
+	slow, started, release := blocking()
+	foo := bulkhead.Wrap(slow)
+
+	done := make(chan error)
+	go func() { done <- foo(mock.Input()) }()
+	<-started
+
+	err := foo(mock.Input())
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(err, http.StatusServiceUnavailable)),
+		it.Equal(err.(*µ.Output).GetHeader("Retry-After"), "1"),
+		it.Equal(bulkhead.Stats(), µ.BulkheadStats{InFlight: 1, MaxInFlight: 1, Rejected: 1}),
+	)
+
+	close(release)
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(<-done, http.StatusOK)),
+		it.Equal(bulkhead.Stats().InFlight, 0),
+	)
+}
+
+func TestBulkheadQueueTimeout(t *testing.T) {
+	bulkhead := µ.NewBulkhead(1, 1, 20*time.Millisecond)
+	slow, started, release := blocking()
+	foo := bulkhead.Wrap(slow)
+
+	done := make(chan error)
+	go func() { done <- foo(mock.Input()) }()
+	<-started
+
+	queued := make(chan error)
+	go func() { queued <- foo(mock.Input()) }()
+	for bulkhead.Stats().Queued == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(foo(mock.Input()), http.StatusServiceUnavailable)),
+		it.Nil(mock.CheckStatusCode(<-queued, http.StatusServiceUnavailable)),
+	)
+
+	close(release)
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(<-done, http.StatusOK)),
+		it.Equal(bulkhead.Stats(), µ.BulkheadStats{MaxInFlight: 1, MaxQueued: 1, Rejected: 2}),
+	)
+}
+
+func TestBulkheadQueue(t *testing.T) {
+	bulkhead := µ.NewBulkhead(1, 1, 0)
+	slow, started, release := blocking()
+	foo := bulkhead.Wrap(slow)
+
+	done := make(chan error)
+	go func() { done <- foo(mock.Input()) }()
+	<-started
+
+	queued := make(chan error)
+	go func() { queued <- foo(mock.Input()) }()
+	for bulkhead.Stats().Queued == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(<-done, http.StatusOK)),
+		it.Nil(mock.CheckStatusCode(<-queued, http.StatusOK)),
+		it.Equal(bulkhead.Stats(), µ.BulkheadStats{MaxInFlight: 1, MaxQueued: 1}),
+	)
+}
+
+func TestBulkheadGroup(t *testing.T) {
+	bulkhead := µ.NewBulkhead(1, 0, 0)
+	slow, started, release := blocking()
+	foo := µ.NewRoutes(
+		µ.Mount(
+			µ.Route(µ.URI(µ.Path("api"))),
+			µ.GET(µ.URI(µ.Path("users")), bulkhead.Wrap(slow)),
+			µ.PUT(µ.URI(µ.Path("users")), bulkhead.Wrap(mock.Output(http.StatusOK, "put"))),
+			µ.POST(µ.URI(µ.Path("users")), mock.Output(http.StatusOK, "post")),
+		),
+	).Endpoint()
+
+	done := make(chan error)
+	go func() { done <- foo(mock.Input(mock.URL("/api/users"))) }()
+	<-started
+
+	t.Run("CrossVerb", func(t *testing.T) {
+		err := foo(mock.Input(mock.Method("POST"), mock.URL("/api/users")))
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusOK)),
+			it.Equal(bulkhead.Stats().Rejected, 0),
+		)
+	})
+
+	t.Run("Shared", func(t *testing.T) {
+		err := foo(mock.Input(mock.Method("PUT"), mock.URL("/api/users")))
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusServiceUnavailable)),
+			it.Equal(bulkhead.Stats().Rejected, 1),
+		)
+	})
+
+	close(release)
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(<-done, http.StatusOK)),
+		it.Equal(bulkhead.Stats().InFlight, 0),
+	)
+}
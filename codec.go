@@ -0,0 +1,193 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ajg/form"
+)
+
+/*
+Codec encodes the output of Map to the media type
+*/
+type Codec struct {
+	MediaType string
+	Encode    func(any) ([]byte, error)
+}
+
+// Built-in codecs
+var (
+	CodecJSON = Codec{"application/json", json.Marshal}
+	CodecForm = Codec{"application/x-www-form-urlencoded", encodeForm}
+	CodecText = Codec{"text/plain", encodeText}
+)
+
+func encodeForm(val any) ([]byte, error) {
+	str, err := form.EncodeToString(val)
+	return []byte(str), err
+}
+
+// encodeText uses encoding.TextMarshaler or fmt.Stringer if type implements it
+func encodeText(val any) ([]byte, error) {
+	switch v := val.(type) {
+	case encoding.TextMarshaler:
+		return v.MarshalText()
+	case fmt.Stringer:
+		return []byte(v.String()), nil
+	default:
+		return []byte(fmt.Sprint(reflect.Indirect(reflect.ValueOf(val)).Interface())), nil
+	}
+}
+
+var (
+	codecs   atomic.Pointer[[]Codec]
+	codecsMu sync.Mutex
+)
+
+func init() {
+	codecs.Store(&[]Codec{CodecJSON, CodecForm, CodecText})
+}
+
+/*
+RegisterCodec makes the codec available to Map. The codec replaces one
+registered for the same media type. Codecs are preferred in the order of
+registration if client accepts multiple media types with same quality,
+JSON is the first one.
+
+	µ.RegisterCodec(µ.Codec{MediaType: "application/cbor", Encode: cbor.Marshal})
+*/
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	seq := append([]Codec{}, *codecs.Load()...)
+	for i, c := range seq {
+		if c.MediaType == codec.MediaType {
+			seq[i] = codec
+			codecs.Store(&seq)
+			return
+		}
+	}
+
+	seq = append(seq, codec)
+	codecs.Store(&seq)
+}
+
+/*
+negotiate selects codec acceptable by the client, using q-values of
+the Accept header. The most specific media range defines the quality of
+media type (e.g. text/plain overrides text/*). The first codec is used
+if the header is not defined.
+*/
+func negotiate(accept string, seq []Codec) (Codec, bool) {
+	if accept == "" {
+		return seq[0], true
+	}
+
+	ranges := parseAccept(accept)
+
+	best, quality := -1, 0.0
+	for i, codec := range seq {
+		if q := qualityOf(codec.MediaType, ranges); q > quality {
+			best, quality = i, q
+		}
+	}
+
+	if best == -1 {
+		return Codec{}, false
+	}
+	return seq[best], true
+}
+
+type mediaRange struct {
+	media string
+	q     float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	seq := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		media, params, _ := strings.Cut(part, ";")
+		media = strings.ToLower(strings.TrimSpace(media))
+		if media == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				if x, err := strconv.ParseFloat(val, 64); err == nil {
+					q = x
+				}
+			}
+		}
+		seq = append(seq, mediaRange{media: media, q: q})
+	}
+	return seq
+}
+
+// qualityOf media type is q-value of the most specific matching range
+func qualityOf(media string, ranges []mediaRange) float64 {
+	mtype, _, _ := strings.Cut(media, "/")
+
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.media == media:
+			s = 2
+		case r.media == mtype+"/*":
+			s = 1
+		case r.media == "*/*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// notAcceptable lists available media types
+func notAcceptable(seq []Codec) error {
+	types := make([]string, len(seq))
+	for i, codec := range seq {
+		types[i] = codec.MediaType
+	}
+
+	issue := NewIssue(http.StatusNotAcceptable)
+	issue.Detail = "available media types: " + strings.Join(types, ", ")
+
+	out := NewOutput(http.StatusNotAcceptable)
+	out.setIssue(issue, fmt.Errorf("%s", issue.Detail))
+	return out
+}
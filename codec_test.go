@@ -0,0 +1,101 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+type codecT struct {
+	Name string `json:"name" form:"name"`
+}
+
+func (t codecT) String() string { return "name is " + t.Name }
+
+func TestMapNegotiate(t *testing.T) {
+	defer µ.RestoreCodecs()()
+	µ.RegisterCodec(µ.Codec{
+		MediaType: "application/x-test",
+		Encode:    func(any) ([]byte, error) { return []byte("test"), nil },
+	})
+
+	name := µ.Optics1[codecT, string]()
+	foo := mock.Endpoint(
+		µ.GET(
+			µ.URI(µ.Path("foo"), µ.Path(name)),
+			µ.Map(func(ctx *µ.Context, t *codecT) (*codecT, error) { return t, nil }),
+		),
+	)
+
+	for accept, expect := range map[string][2]string{
+		"":                                  {"application/json", `{"name":"bar"}`},
+		"*/*":                               {"application/json", `{"name":"bar"}`},
+		"application/json":                  {"application/json", `{"name":"bar"}`},
+		"text/plain":                        {"text/plain", "name is bar"},
+		"text/*":                            {"text/plain", "name is bar"},
+		"application/x-www-form-urlencoded": {"application/x-www-form-urlencoded", "name=bar"},
+		"application/json;q=0.5, text/plain;q=0.8":    {"text/plain", "name is bar"},
+		"application/*;q=0.9, application/json;q=0.1": {"application/x-www-form-urlencoded", "name=bar"},
+		"text/html, */*;q=0.1":                        {"application/json", `{"name":"bar"}`},
+		"application/x-test":                          {"application/x-test", "test"},
+	} {
+		err := foo(mock.Input(mock.URL("/foo/bar"), mock.Header("Accept", accept)))
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusOK)),
+			it.Equal(err.(*µ.Output).GetHeader("Content-Type"), expect[0]),
+			it.Equal(err.(*µ.Output).Body, expect[1]),
+		)
+	}
+}
+
+func TestMapNotAcceptable(t *testing.T) {
+	calls := 0
+	name := µ.Optics1[codecT, string]()
+	foo := mock.Endpoint(
+		µ.POST(
+			µ.URI(µ.Path("foo"), µ.Path(name)),
+			µ.Map(func(ctx *µ.Context, t *codecT) (*codecT, error) {
+				calls++
+				return t, nil
+			}),
+		),
+	)
+
+	for _, accept := range []string{"text/html", "application/json;q=0, text/html"} {
+		err := foo(mock.Input(mock.Method("POST"), mock.URL("/foo/bar"), mock.Header("Accept", accept)))
+
+		var issue µ.Issue
+		json.Unmarshal([]byte(err.(*µ.Output).Body), &issue)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusNotAcceptable)),
+			it.String(issue.Detail).Contain("application/json, application/x-www-form-urlencoded, text/plain"),
+		)
+	}
+
+	it.Then(t).Should(
+		it.Equal(calls, 0),
+	)
+}
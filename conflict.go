@@ -0,0 +1,259 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of conflicts between routes
+const (
+	// ConflictDuplicate is the route declared twice for same HTTP verb,
+	// neither of routes has guards (e.g. µ.Param, µ.Header) beyond the handler,
+	// guards of the shared Mount prefix do not tell the routes apart
+	ConflictDuplicate = "duplicate"
+	// ConflictAmbiguous is the route unreachable because other route
+	// matches same path with higher priority (e.g. `:` and `_` siblings)
+	ConflictAmbiguous = "ambiguous"
+)
+
+/*
+Conflict describes the route that cannot be reached by the router
+*/
+type Conflict struct {
+	Kind   string // kind of conflict
+	Method string // HTTP verb of the route
+	Path   string // path pattern of the route
+	With   string // path pattern of conflicting route
+}
+
+func (c Conflict) Error() string {
+	return fmt.Sprintf("%s route %s %s conflicts with %s", c.Kind, verbOf(c.Method), c.Path, c.With)
+}
+
+/*
+Conflicts is a list of conflicting routes
+*/
+type Conflicts []Conflict
+
+func (seq Conflicts) Error() string {
+	msg := make([]string, len(seq))
+	for i, c := range seq {
+		msg[i] = c.Error()
+	}
+	return strings.Join(msg, "\n")
+}
+
+/*
+ValidateRoutes builds routing table and reports routes that are exact
+duplicates or unreachable due to ambiguous wildcard siblings. It returns
+Conflicts if any route cannot be reached. Routes with same path and verb
+are not duplicates if they are dispatched by guards, e.g.
+
+	µ.GET(µ.URI(µ.Path("echo")), µ.Accept.Text, text)
+	µ.GET(µ.URI(µ.Path("echo")), µ.Accept.JSON, json)
+
+Guards are Param, Header, JWT and Bind with query, header, jwt or body fields.
+Optional variants (e.g. ParamMaybe), FMap and Map are not guards. Other
+endpoints are assumed to be guards, except the last one that is assumed
+to be the handler.
+
+Routes are never shadowed by PathAll, the router backtracks to more specific
+siblings before the catch-all wildcard.
+
+	func TestRoutes(t *testing.T) {
+		if err := µ.ValidateRoutes(api.Routes()...); err != nil {
+			t.Error(err)
+		}
+	}
+*/
+func ValidateRoutes(seq ...Routable) error {
+	return NewRoutes(seq...).Validate()
+}
+
+/*
+Validate reports routes of the routing table that cannot be reached,
+see ValidateRoutes for details.
+*/
+func (root *Node) Validate() error {
+	paths := map[*Node]string{}
+	walkPath(root, "", func(path string, node *Node) { paths[node] = path })
+
+	seq := Conflicts{}
+	values := make([]string, 0, 20)
+	root.Walk(func(_ int, node *Node) {
+		for i, spec := range node.Spec {
+			for _, prev := range node.Spec[:i] {
+				if isVerbOverlap(prev.Method, spec.Method) && !isGuarded(prev) && !isGuarded(spec) && isSameGuards(prev, spec) {
+					seq = append(seq, Conflict{
+						Kind:   ConflictDuplicate,
+						Method: spec.Method,
+						Path:   paths[node],
+						With:   paths[node],
+					})
+					break
+				}
+			}
+
+			samples := samplePaths(spec.Segments)
+			if len(samples) == 0 {
+				// constraint cannot be sampled, reachability is not known
+				continue
+			}
+
+			// the route is reachable if any sample is routed to it,
+			// e.g. literal `0` takes only one value of `int` constraint
+			var hit *Node
+			for _, sample := range samples {
+				values = values[:0]
+				if hit = root.lookup(sample, verbToLookup(spec.Method), &values); hit == node {
+					break
+				}
+			}
+			if hit == node {
+				continue
+			}
+
+			seq = append(seq, Conflict{
+				Kind:   ConflictAmbiguous,
+				Method: spec.Method,
+				Path:   paths[node],
+				With:   paths[hit],
+			})
+		}
+	})
+
+	if len(seq) == 0 {
+		return nil
+	}
+
+	return seq
+}
+
+func walkPath(node *Node, prefix string, f func(string, *Node)) {
+	path := prefix + node.Path
+	if node.name != "" {
+		path += "{" + node.name + "}"
+	}
+	f(path, node)
+	for _, n := range node.Heir {
+		walkPath(n, path, f)
+	}
+}
+
+// maxSamplePaths limits combinations of constraint samples per route
+const maxSamplePaths = 64
+
+// samplePaths builds request paths matched by the pattern,
+// wildcards are substituted with a value that never equals to literal,
+// constrained wildcards with each sample of constraint. It returns nil
+// if any constraint cannot be sampled.
+func samplePaths(path []Segment) []string {
+	if len(path) == 0 {
+		return []string{"/"}
+	}
+
+	seq := []string{""}
+	for i, segment := range path {
+		var values []string
+		switch {
+		case segment.pattern != nil:
+			values = []string{strings.ReplaceAll(segment.pattern.expr, ":", "\x00")}
+		case segment.is != nil:
+			if len(segment.is.Samples) == 0 {
+				return nil
+			}
+			values = segment.is.Samples
+		case segment.kind(i == len(path)-1) == nodeLiteral:
+			values = []string{segment.path}
+		default:
+			values = []string{"\x00"}
+		}
+
+		next := make([]string, 0, len(seq)*len(values))
+		for _, prefix := range seq {
+			for _, value := range values {
+				if len(next) == maxSamplePaths {
+					break
+				}
+				next = append(next, prefix+"/"+value)
+			}
+		}
+		seq = next
+	}
+
+	return seq
+}
+
+// isGuarded checks if the route has guards beyond path, verb and handler.
+// Guards are declared by endpoints (e.g. Param, Header), the last endpoint
+// of the route is assumed to be the handler if it is not declared.
+func isGuarded(spec Spec) bool {
+	n := spec.guard
+	if spec.handler {
+		n--
+	}
+	return n > 0
+}
+
+// isSameGuards checks if routes are mounted under same guarded prefixes,
+// the prefix guards cannot tell apart routes of the group
+func isSameGuards(a, b Spec) bool {
+	if len(a.guards) != len(b.guards) {
+		return false
+	}
+
+	for i := range a.guards {
+		if a.guards[i] != b.guards[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isVerbOverlap checks if both verbs matches same request,
+// routes with unknown verbs are not comparable
+func isVerbOverlap(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+
+	return a == b || a == Any || b == Any
+}
+
+// verbToLookup returns the verb the route is looked up with, any node
+// matches routes accepting any verb
+func verbToLookup(verb string) string {
+	if verb == Any {
+		return ""
+	}
+	return verb
+}
+
+func verbOf(verb string) string {
+	switch verb {
+	case "":
+		return "?"
+	case Any:
+		return "*"
+	default:
+		return verb
+	}
+}
@@ -0,0 +1,277 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+func TestValidateRoutes(t *testing.T) {
+	type myT struct{ ID, Path string }
+	id, path := µ.Optics2[myT, string, string]()
+
+	err := µ.ValidateRoutes(
+		µ.GET(µ.URI()),
+		µ.GET(µ.URI(µ.Path("users"))),
+		µ.POST(µ.URI(µ.Path("users"))),
+		µ.GET(µ.URI(µ.Path("users"), µ.Path(id))),
+		µ.GET(µ.URI(µ.Path("users"), µ.Path(id), µ.Path("posts"))),
+		µ.GET(µ.URI(µ.Path("static"), µ.PathAll(path))),
+	)
+
+	it.Then(t).Should(
+		it.Nil(err),
+	)
+}
+
+func TestValidateRoutesDuplicate(t *testing.T) {
+	err := µ.ValidateRoutes(
+		µ.GET(µ.URI(µ.Path("users"))),
+		µ.POST(µ.URI(µ.Path("users"))),
+		µ.ANY(µ.URI(µ.Path("users"))),
+		µ.Route(µ.URI(µ.Path("users"))),
+	)
+
+	it.Then(t).Should(
+		it.Equiv(err.(µ.Conflicts), µ.Conflicts{
+			{Kind: µ.ConflictDuplicate, Method: µ.Any, Path: "/users", With: "/users"},
+		}),
+	)
+}
+func TestValidateRoutesGuarded(t *testing.T) {
+	type myT struct{ Q, V string }
+	q, v := µ.Optics2[myT, string, string]()
+	handler := func(*µ.Context) error { return nil }
+
+	err := µ.ValidateRoutes(
+		µ.GET(µ.URI(µ.Path("echo")), µ.Accept.Text, handler),
+		µ.GET(µ.URI(µ.Path("echo")), µ.Accept.JSON, handler),
+		µ.GET(µ.URI(µ.Path("params")), µ.Param("q", q), handler),
+		µ.GET(µ.URI(µ.Path("params")), µ.Param("v", v), handler),
+		µ.Mount(
+			µ.Route(µ.URI(µ.Path("api")), µ.Header("X-Version", "1")),
+			µ.GET(µ.URI(µ.Path("echo")), handler),
+		),
+		µ.Mount(
+			µ.Route(µ.URI(µ.Path("api")), µ.Header("X-Version", "2")),
+			µ.GET(µ.URI(µ.Path("echo")), handler),
+		),
+	)
+
+	it.Then(t).Should(
+		it.Nil(err),
+	)
+}
+
+func TestValidateRoutesDuplicateHandler(t *testing.T) {
+	handler := func(*µ.Context) error { return nil }
+
+	err := µ.ValidateRoutes(
+		µ.GET(µ.URI(µ.Path("echo")), handler),
+		µ.GET(µ.URI(µ.Path("echo")), µ.Accept.JSON, handler),
+		µ.GET(µ.URI(µ.Path("echo")), handler),
+	)
+
+	it.Then(t).Should(
+		it.Equiv(err.(µ.Conflicts), µ.Conflicts{
+			{Kind: µ.ConflictDuplicate, Method: "GET", Path: "/echo", With: "/echo"},
+		}),
+	)
+}
+
+func TestValidateRoutesDuplicateBind(t *testing.T) {
+	type T struct {
+		ID string `path:"id"`
+	}
+	type Q struct {
+		ID    string `path:"id"`
+		Limit int    `query:"limit"`
+	}
+	req, qry := µ.Bind[T](), µ.Bind[Q]()
+	handler := µ.FMap(func(*µ.Context, *T) error { return nil })
+	search := µ.FMap(func(*µ.Context, *Q) error { return nil })
+
+	err := µ.ValidateRoutes(
+		µ.GET(req.URI("/users/:id"), req.Endpoint(), handler),
+		µ.GET(req.URI("/users/:id"), req.Endpoint(), handler),
+		µ.POST(qry.URI("/users/:id"), qry.Endpoint(), search),
+		µ.POST(qry.URI("/users/:id"), qry.Endpoint(), search),
+	)
+
+	it.Then(t).Should(
+		it.Equiv(err.(µ.Conflicts), µ.Conflicts{
+			{Kind: µ.ConflictDuplicate, Method: "GET", Path: "/users/:", With: "/users/:"},
+		}),
+	)
+}
+
+func TestValidateRoutesGuardedByLastEndpoint(t *testing.T) {
+	err := µ.ValidateRoutes(
+		µ.GET(µ.URI(µ.Path("echo")), µ.Param("v", "a")),
+		µ.GET(µ.URI(µ.Path("echo")), µ.Param("v", "b")),
+		µ.GET(µ.URI(µ.Path("echo")), µ.Header("X-Version", "1")),
+	)
+
+	it.Then(t).Should(
+		it.Nil(err),
+	)
+}
+
+func TestValidateRoutesDuplicateMount(t *testing.T) {
+	echo := µ.URI(µ.Path("echo"))
+	handler := func(*µ.Context) error { return nil }
+
+	err := µ.ValidateRoutes(
+		µ.Mount(
+			µ.Route(µ.URI(µ.Path("api")), µ.Header("X", "1")),
+			µ.GET(echo, handler),
+			µ.GET(echo, handler),
+			µ.Mount(
+				µ.Route(µ.URI(), µ.Header("Y", "1")),
+				µ.POST(echo, handler),
+			),
+			µ.Mount(
+				µ.Route(µ.URI(), µ.Header("Y", "2")),
+				µ.POST(echo, handler),
+				µ.POST(echo, handler),
+			),
+		),
+	)
+
+	it.Then(t).Should(
+		it.Equiv(err.(µ.Conflicts), µ.Conflicts{
+			{Kind: µ.ConflictDuplicate, Method: "GET", Path: "/api/echo", With: "/api/echo"},
+			{Kind: µ.ConflictDuplicate, Method: "POST", Path: "/api/echo", With: "/api/echo"},
+		}),
+	)
+}
+
+func TestValidateRoutesAmbiguous(t *testing.T) {
+	type myT struct{ ID string }
+	id := µ.Optics1[myT, string]()
+
+	err := µ.ValidateRoutes(
+		µ.GET(µ.URI(µ.Path("users"), µ.Path(id), µ.Path("posts"))),
+		µ.GET(µ.URI(µ.Path("users"), µ.PathAny(), µ.Path("posts"))),
+	)
+
+	it.Then(t).Should(
+		it.Equiv(err.(µ.Conflicts), µ.Conflicts{
+			{Kind: µ.ConflictAmbiguous, Method: "GET", Path: "/users/_/posts", With: "/users/:/posts"},
+		}),
+	)
+}
+
+func TestValidateRoutesLiteralAndWildcard(t *testing.T) {
+	type myT struct{ ID, Path string }
+	id, path := µ.Optics2[myT, string, string]()
+
+	err := µ.ValidateRoutes(
+		µ.GET(µ.URI(µ.Path("users"), µ.Path("me"), µ.Path("settings"))),
+		µ.GET(µ.URI(µ.Path("users"), µ.Path(id), µ.Path("posts"))),
+		µ.GET(µ.URI(µ.Path("static"), µ.PathAll(path))),
+		µ.GET(µ.URI(µ.Path("static"), µ.PathAny(), µ.Path("index.html"))),
+	)
+
+	it.Then(t).Should(
+		it.Nil(err),
+	)
+}
+
+func TestValidateRoutesConstraints(t *testing.T) {
+	type myT struct{ ID string }
+	id := µ.Optics1[myT, string]()
+
+	err := µ.ValidateRoutes(
+		µ.GET(µ.URI(µ.Path("items"), µ.Path(id))),
+		µ.GET(µ.URI(µ.Path("items"), µ.Path(id, µ.IsInt()))),
+		µ.GET(µ.URI(µ.Path("items"), µ.Path(id, µ.IsUUID()))),
+		µ.GET(µ.URI(µ.Path("items"), µ.PathAny(µ.IsInt()))),
+	)
+
+	it.Then(t).Should(
+		it.Equiv(err.(µ.Conflicts), µ.Conflicts{
+			{Kind: µ.ConflictAmbiguous, Method: "GET", Path: "/items/_{int}", With: "/items/:{int}"},
+		}),
+	)
+}
+
+func TestValidateRoutesConstraintsWithLiteral(t *testing.T) {
+	type myT struct{ ID string }
+	id := µ.Optics1[myT, string]()
+
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("items"), µ.Path("0")), mock.Output(http.StatusOK, "zero")),
+		µ.GET(µ.URI(µ.Path("items"), µ.Path(id, µ.IsInt())), mock.Output(http.StatusOK, "int")),
+		µ.GET(µ.URI(µ.Path("pages"), µ.Path("new")), mock.Output(http.StatusOK, "new")),
+		µ.GET(µ.URI(µ.Path("pages"), µ.Path(id, µ.IsOneOf("new", "edit"))), mock.Output(http.StatusOK, "oneof")),
+	)
+	endpoint := foo.Endpoint()
+
+	it.Then(t).Should(
+		it.Nil(foo.Validate()),
+		it.Nil(mock.CheckOutput(endpoint(mock.Input(mock.URL("/items/0"))), "zero")),
+		it.Nil(mock.CheckOutput(endpoint(mock.Input(mock.URL("/items/1"))), "int")),
+		it.Nil(mock.CheckOutput(endpoint(mock.Input(mock.URL("/pages/new"))), "new")),
+		it.Nil(mock.CheckOutput(endpoint(mock.Input(mock.URL("/pages/edit"))), "oneof")),
+	)
+}
+
+func TestValidateRoutesConstraintsCoveredByLiterals(t *testing.T) {
+	type myT struct{ ID string }
+	id := µ.Optics1[myT, string]()
+
+	err := µ.ValidateRoutes(
+		µ.GET(µ.URI(µ.Path("pages"), µ.Path("new"))),
+		µ.GET(µ.URI(µ.Path("pages"), µ.Path("edit"))),
+		µ.GET(µ.URI(µ.Path("pages"), µ.Path(id, µ.IsOneOf("new", "edit")))),
+	)
+
+	it.Then(t).Should(
+		it.Equiv(err.(µ.Conflicts), µ.Conflicts{
+			{Kind: µ.ConflictAmbiguous, Method: "GET", Path: "/pages/:{oneof new|edit}", With: "/pages/edit"},
+		}),
+	)
+}
+
+func TestValidateRoutesNotShadowedByPathAll(t *testing.T) {
+	type myT struct{ ID, Path string }
+	id, path := µ.Optics2[myT, string, string]()
+
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("static"), µ.PathAll(path)), mock.Output(http.StatusOK, "all")),
+		µ.GET(µ.URI(µ.Path("static"), µ.Path("index.html")), mock.Output(http.StatusOK, "index")),
+		µ.GET(µ.URI(µ.Path("static"), µ.Path(id), µ.Path("meta")), mock.Output(http.StatusOK, "meta")),
+		µ.GET(µ.URI(µ.Path("static"), µ.PathAny(), µ.Path("raw"), µ.PathAll(path)), mock.Output(http.StatusOK, "raw")),
+	)
+	endpoint := foo.Endpoint()
+
+	it.Then(t).Should(
+		it.Nil(foo.Validate()),
+		it.Nil(mock.CheckOutput(endpoint(mock.Input(mock.URL("/static/a/b"))), "all")),
+		it.Nil(mock.CheckOutput(endpoint(mock.Input(mock.URL("/static/index.html"))), "index")),
+		it.Nil(mock.CheckOutput(endpoint(mock.Input(mock.URL("/static/a/meta"))), "meta")),
+		it.Nil(mock.CheckOutput(endpoint(mock.Input(mock.URL("/static/a/raw/b/c"))), "raw")),
+	)
+}
@@ -20,8 +20,11 @@ package gouldian
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/fogfish/gouldian/v2/internal/optics"
 )
@@ -76,16 +79,127 @@ func (ctx *Context) Free() {
 
 // Put injects value to the context
 func (ctx *Context) Put(lens optics.Lens, str string) error {
+	if err := ctx.put(lens, str); err != nil {
+		return ErrNoMatch
+	}
+
+	return nil
+}
+
+// put injects value to the context, it returns decode error as-is
+func (ctx *Context) put(lens optics.Lens, str string) error {
+	return ctx.decode(lens, decoderOf(lens), str)
+}
+
+// decode injects value to the context, the decoder of lens is resolved
+// by endpoint (see decoderOf). It returns decode error as-is.
+func (ctx *Context) decode(lens optics.Lens, decoder optics.Decoder, str string) error {
+	if decoder != nil {
+		val, err := decoder.Decode(str)
+		if err != nil {
+			return err
+		}
+
+		ctx.morphism = append(ctx.morphism, optics.Morphism{Lens: lens, Decoded: val})
+		return nil
+	}
+
 	val, err := lens.FromString(str)
 	if err != nil {
-		return ErrNoMatch
+		return err
 	}
 
 	ctx.morphism = append(ctx.morphism, optics.Morphism{Lens: lens, Value: val})
 	return nil
 }
 
-// Get decodes context into structure
+// decoderOf returns the lens if it decodes value itself, nil otherwise
+func decoderOf(lens optics.Lens) optics.Decoder {
+	if l, ok := lens.(Lens); ok {
+		lens = l.Lens
+	}
+
+	if decoder, ok := lens.(optics.Decoder); ok {
+		return decoder
+	}
+	return nil
+}
+
+// defaultOf returns morphism with default value of lens, the value is
+// defined by `default` tag. It returns nil if lens has no default value.
+func defaultOf(lens optics.Lens) *optics.Morphism {
+	l, ok := lens.(Lens)
+	if !ok {
+		return nil
+	}
+
+	tag, ok := l.field.Tag.Lookup("default")
+	if !ok {
+		return nil
+	}
+
+	val, err := lens.FromString(tag)
+	if err != nil {
+		panic(fmt.Errorf("default value %q of %s.%s is not valid: %w", tag, l.target, l.field.Name, err))
+	}
+
+	return &optics.Morphism{Lens: lens, Value: val, Default: true}
+}
+
+// putDefault injects default value to the context, it fails if lens
+// has no default value
+func (ctx *Context) putDefault(def *optics.Morphism) bool {
+	if def == nil {
+		return false
+	}
+
+	ctx.morphism = append(ctx.morphism, *def)
+	return true
+}
+
+// putValues injects value of term to the context, every value of repeated
+// term is injected if lens focuses on slice, the first one otherwise.
+// Values are split on separator (see optics.Multi).
+// It returns decode error as-is.
+func (ctx *Context) putValues(lens optics.Lens, decoder optics.Decoder, multi optics.Multi, seq []string) error {
+	if multi == nil {
+		return ctx.decode(lens, decoder, seq[0])
+	}
+
+	val, err := multi.FromStrings(seq)
+	if err != nil {
+		return err
+	}
+
+	ctx.morphism = append(ctx.morphism, optics.Morphism{Lens: lens, Decoded: val})
+	return nil
+}
+
+// multiOf returns the lens if it focuses on slice, nil otherwise
+func multiOf(lens optics.Lens) optics.Multi {
+	if l, ok := lens.(Lens); ok {
+		lens = l.Lens
+	}
+
+	if multi, ok := lens.(optics.Multi); ok {
+		return multi
+	}
+	return nil
+}
+
+// invalid is the reason of decode failure, it refers to the failed
+// element of slice
+func invalid(err error) string {
+	var elem optics.ElementError
+	if errors.As(err, &elem) {
+		return "element " + strconv.Itoa(elem.Index) + " " + reasonInvalid
+	}
+	return reasonInvalid
+}
+
+// Get decodes context into structure. Fields that are not matched by
+// lenses keep zero value unless `default` tag is defined, pointer fields
+// stay nil. Use IsBound to check if value of field is supplied by request.
 func FromContext[S any](ctx *Context, val *S) error {
 	if err := optics.Morph(ctx.morphism, val); err != nil {
 		return err
@@ -109,3 +223,21 @@ func (ctx *Context) cacheBody() error {
 
 	return nil
 }
+
+/*
+IsBound checks if value of the field is supplied by HTTP request. The field
+is not bound if the term is missing, its default value is not supplied
+by request either.
+
+	µ.FMap(func(ctx *µ.Context, req *Request) error {
+		if !ctx.IsBound("Limit") { ... }
+	})
+*/
+func (ctx *Context) IsBound(field string) bool {
+	for _, m := range ctx.morphism {
+		if l, ok := m.Lens.(Lens); ok && !m.Default && l.field.Name == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+type defaultT struct {
+	ID     string   `path:"id"`
+	Limit  int      `query:"limit,optional" default:"25"`
+	Sort   string   `query:"sort" default:"asc"`
+	Cursor *string  `query:"cursor,optional"`
+	Tags   []string `query:"tag,optional" default:"a,b"`
+	Tenant string   `header:"X-Tenant" default:"acme"`
+	User   string   `jwt:"sub,optional" default:"anonymous"`
+}
+
+func TestDefault(t *testing.T) {
+	var (
+		val   defaultT
+		bound []string
+	)
+	req := µ.Bind[defaultT]()
+	foo := mock.Endpoint(
+		µ.GET(
+			req.URI("/items/:id"),
+			req.Endpoint(),
+			µ.FMap(func(ctx *µ.Context, t *defaultT) error {
+				val = *t
+				bound = bound[:0]
+				for _, field := range []string{"ID", "Limit", "Sort", "Cursor", "Tags", "Tenant", "User"} {
+					if ctx.IsBound(field) {
+						bound = append(bound, field)
+					}
+				}
+				return nil
+			}),
+		),
+	)
+
+	t.Run("Missing", func(t *testing.T) {
+		err := foo(mock.Input(mock.URL("/items/a1"), mock.JWT(µ.Token{})))
+
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(val.ID, "a1"),
+			it.Equal(val.Limit, 25),
+			it.Equal(val.Sort, "asc"),
+			it.True(val.Cursor == nil),
+			it.Seq(val.Tags).Equal("a", "b"),
+			it.Equal(val.Tenant, "acme"),
+			it.Equal(val.User, "anonymous"),
+			it.Seq(bound).Equal("ID"),
+		)
+	})
+
+	t.Run("Supplied", func(t *testing.T) {
+		err := foo(
+			mock.Input(
+				mock.URL("/items/a1?limit=0&sort=desc&cursor=c1&tag=x"),
+				mock.Header("X-Tenant", "corp"),
+				mock.JWT(µ.Token{"sub": "joe"}),
+			),
+		)
+
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(val.Limit, 0),
+			it.Equal(val.Sort, "desc"),
+			it.Equal(*val.Cursor, "c1"),
+			it.Seq(val.Tags).Equal("x"),
+			it.Equal(val.Tenant, "corp"),
+			it.Equal(val.User, "joe"),
+			it.Seq(bound).Equal("ID", "Limit", "Sort", "Cursor", "Tags", "Tenant", "User"),
+		)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		err := foo(mock.Input(mock.URL("/items/a1?limit=abc"), mock.JWT(µ.Token{})))
+
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(val.Limit, 0),
+		)
+	})
+}
+
+func TestDefaultOptics(t *testing.T) {
+	type T struct {
+		Limit int `default:"25"`
+	}
+	limit := µ.Optics1[T, int]()
+
+	foo := mock.Endpoint(
+		µ.GET(
+			µ.URI(),
+			µ.Param("limit", limit),
+			µ.FMap(func(ctx *µ.Context, t *T) error {
+				if ctx.IsBound("Limit") {
+					return mock.Output(http.StatusOK, "bound")(ctx)
+				}
+				return mock.Output(http.StatusOK, "default")(ctx)
+			}),
+		),
+	)
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckOutput(foo(mock.Input(mock.URL("/"))), "default")),
+		it.Nil(mock.CheckOutput(foo(mock.Input(mock.URL("/?limit=25"))), "bound")),
+	)
+}
+
+func TestDefaultInvalid(t *testing.T) {
+	type T struct {
+		Limit int `default:"abc"`
+	}
+	limit := µ.Optics1[T, int]()
+
+	it.Then(t).Should(
+		it.Fail(func() { µ.ParamMaybe("limit", limit) }),
+	)
+}
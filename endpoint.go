@@ -18,6 +18,12 @@
 
 package gouldian
 
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
 /*
 
 Endpoint is a composable function that abstract HTTP endpoint.
@@ -64,12 +70,11 @@ func (a Endpoint) Then(b Endpoint) Endpoint {
 // Or builds co-product Endpoint
 func (a Endpoint) Or(b Endpoint) Endpoint {
 	return func(http *Context) (err error) {
-		switch err := a(http).(type) {
-		case NoMatch:
-			return b(http)
-		default:
-			return err
+		err = a(http)
+		if _, ok := err.(NoMatch); ok {
+			return mostSpecific(err, b(http))
 		}
+		return err
 	}
 }
 
@@ -77,7 +82,79 @@ func (a Endpoint) Or(b Endpoint) Endpoint {
 
 Routable is endpoint with routing metadata
 */
-type Routable func() ([]string, Endpoint)
+type Routable func() Spec
+
+/*
+
+Spec is the routing metadata of endpoint, it defines how the endpoint
+is attached to the routing table.
+*/
+type Spec struct {
+	Name     string            // name of the route, see Name
+	Method   string            // HTTP verb, empty if verb is not known to router
+	Segments []Segment         // path pattern, each segment is either literal or wildcard
+	Meta     map[string]string // metadata attached to the route
+	Func     Endpoint          // endpoint
+	Group    []Spec            // routes of the group, see Mount
+
+	guard   int            // number of endpoints that dispatch requests, see declaration
+	handler bool           // the last endpoint is not declared, it is assumed to be handler
+	guards  []*Spec        // guarded prefixes the route is mounted under, see Mount
+	query   []ReverseQuery // query params declared by endpoints, see Reverse
+}
+
+/*
+
+declaration is routing metadata of endpoint, it is discovered by Route
+when the endpoint is composed into the route. Endpoints are functions,
+the declared one returns its declaration if it is called with nil context.
+Endpoints that are not declared are assumed to be guards.
+*/
+type declaration struct {
+	guard bool           // endpoint dispatches requests between routes (e.g. Param)
+	query []ReverseQuery // query params matched by endpoint
+}
+
+func (declaration) Error() string { return "declaration of endpoint" }
+
+// declare attaches declaration to the endpoint. The function is not
+// inlined, every declared endpoint shares the code of closure.
+//
+//go:noinline
+func declare(d declaration, endpoint Endpoint) Endpoint {
+	return func(ctx *Context) error {
+		if ctx == nil {
+			return d
+		}
+		return endpoint(ctx)
+	}
+}
+
+// codeOfDeclared is the code of closure built by declare
+var codeOfDeclared = reflect.ValueOf(declare(declaration{}, nil)).Pointer()
+
+// declarationOf returns declaration of the endpoint, false if the endpoint
+// is not declared
+func declarationOf(endpoint Endpoint) (declaration, bool) {
+	if endpoint == nil || reflect.ValueOf(endpoint).Pointer() != codeOfDeclared {
+		return declaration{}, false
+	}
+
+	d, ok := endpoint(nil).(declaration)
+	return d, ok
+}
+
+// declarationsOf merges declarations of endpoints, the sequence is guard
+// if any of endpoints is guard or it is not declared
+func declarationsOf(seq []Endpoint) declaration {
+	var decl declaration
+	for _, endpoint := range seq {
+		d, ok := declarationOf(endpoint)
+		decl.guard = decl.guard || !ok || d.guard
+		decl.query = append(decl.query, d.query...)
+	}
+	return decl
+}
 
 /*
 
@@ -88,15 +165,117 @@ type Router interface {
 	Endpoint() Endpoint
 }
 
-// NoMatch is returned by Endpoint if Context is not matched.
-type NoMatch int
+/*
+
+NoMatch is returned by Endpoint if Context is not matched. The value
+optionally diagnoses the failure: the combinator that has failed, the key
+it has been looking for (e.g. name of param or header) and the reason.
+The status is HTTP status code used to report the failure to the client,
+zero value means the failure is not reportable (e.g. the request does not
+match the route).
+*/
+type NoMatch struct {
+	Status int    // HTTP status code to report the failure, 0 if not known
+	Source string // failed combinator: path, param, header, body or jwt
+	Key    string // name of param, header, etc.
+	Reason string // human readable reason of the failure
+}
 
 func (err NoMatch) Error() string {
-	return "No Match"
+	if err.Reason == "" {
+		return "No Match"
+	}
+
+	return "No Match: " + err.Detail()
+}
+
+// Detail is human readable explanation of the failure
+func (err NoMatch) Detail() string {
+	if err.Key == "" {
+		return err.Source + " " + err.Reason
+	}
+
+	return err.Source + " " + err.Key + " " + err.Reason
 }
 
+/*
+
+Output converts NoMatch to HTTP response with RFC 7807 body. NoMatch
+without status code is reported as 501 Not Implemented. The failed param,
+header, etc. is listed by `invalid-params` member.
+*/
+func (err NoMatch) Output() *Output {
+	status := err.Status
+	if status == 0 {
+		status = http.StatusNotImplemented
+	}
+
+	issue := NewIssue(status)
+	if err.Reason != "" {
+		issue.Detail = err.Detail()
+	}
+
+	if err.Key != "" {
+		issue.InvalidParams = []InvalidParam{{Name: err.Key, Reason: err.Reason}}
+	}
+
+	out := NewOutput(status)
+	out.setIssue(issue, err)
+	return out
+}
+
+// rank of the failure, a failure that has reached deeper into the request
+// is more specific: malformed values are more specific than missing ones,
+// which are more specific than failed credentials and unsupported media type.
+func (err NoMatch) rank() int {
+	switch err.Status {
+	case 0:
+		return 0
+	case http.StatusBadRequest:
+		if strings.HasSuffix(err.Reason, reasonInvalid) {
+			return 5
+		}
+		return 4
+	case http.StatusForbidden:
+		return 3
+	case http.StatusUnauthorized:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// mostSpecific returns the most specific NoMatch of two, b is returned
+// as-is unless it is NoMatch
+func mostSpecific(a, b error) error {
+	y, ok := b.(NoMatch)
+	if !ok {
+		return b
+	}
+
+	if x, ok := a.(NoMatch); ok && x.rank() >= y.rank() {
+		return a
+	}
+
+	return b
+}
+
+// reasons of NoMatch failures
+const (
+	reasonMissing  = "is missing"
+	reasonMismatch = "is not matched"
+	reasonInvalid  = "is not valid"
+)
+
 // ErrNoMatch constant
-var ErrNoMatch error = NoMatch(255)
+var ErrNoMatch error = NoMatch{}
+
+// ErrNotFound is returned by router if the path is not known
+var ErrNotFound error = NoMatch{
+	Status: http.StatusNotFound,
+	Source: "path",
+	Reason: "is not found",
+}
 
 /*
 
@@ -123,16 +302,18 @@ func (seq Endpoints) Join(ctx *Context) (err error) {
 Or builds co-product endpoint from sequence
 */
 func (seq Endpoints) Or(ctx *Context) (err error) {
+	failure := ErrNoMatch
 	for _, f := range seq {
 		x := f(ctx)
 		switch err := x.(type) {
 		case NoMatch:
+			failure = mostSpecific(failure, x)
 			continue
 		default:
 			return err
 		}
 	}
-	return ErrNoMatch
+	return failure
 }
 
 /*
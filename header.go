@@ -48,15 +48,17 @@ Header combinator defines primitives to match Headers of HTTP requests.
 	) == nil
 */
 func Header[T MatchableHeaderValues](hdr string, val T) Endpoint {
+	guard := declaration{guard: true}
+
 	switch v := any(val).(type) {
 	case string:
-		return HeaderOf[string](hdr).Is(v)
+		return declare(guard, HeaderOf[string](hdr).Is(v))
 	case int:
-		return HeaderOf[int](hdr).Is(v)
+		return declare(guard, HeaderOf[int](hdr).Is(v))
 	case time.Time:
-		return HeaderOf[time.Time](hdr).Is(v)
+		return declare(guard, HeaderOf[time.Time](hdr).Is(v))
 	case Lens:
-		return HeaderOf[Lens](hdr).To(v)
+		return declare(guard, HeaderOf[Lens](hdr).To(v))
 	default:
 		panic("type system failure")
 	}
@@ -71,13 +73,14 @@ HeaderAny is a wildcard matcher of header. It fails if header is not defined.
 	e(mock.Input()) != nil
 */
 func HeaderAny(hdr string) Endpoint {
-	return HeaderOf[string](hdr).Any
+	return declare(declaration{guard: true}, HeaderOf[string](hdr).Any)
 }
 
 /*
 HeaderMaybe matches header value to the request context. It uses lens abstraction to
 decode HTTP header into Golang type. The Endpoint does not cause no-match
 if header value cannot be decoded to the target type. See optics.Lens type for details.
+The default value of lens, defined by `default` tag, is used if header is missing.
 
 	type myT struct{ Val string }
 
@@ -86,26 +89,46 @@ if header value cannot be decoded to the target type. See optics.Lens type for d
 	e(mock.Input(mock.Header("X-Foo", "Bar"))) == nil
 */
 func HeaderMaybe(header string, lens Lens) Endpoint {
-	return func(ctx *Context) error {
-		if opt := ctx.Request.Header.Get(string(header)); opt != "" {
-			ctx.Put(lens, opt)
+	multi := multiOf(lens)
+	decoder := decoderOf(lens)
+	def := defaultOf(lens)
+
+	return declare(declaration{}, func(ctx *Context) error {
+		if seq := headerValues(ctx, header); seq != nil {
+			ctx.putValues(lens, decoder, multi, seq)
+		} else {
+			ctx.putDefault(def)
 		}
 		return nil
+	})
+}
+
+// headerValues returns every value of multi-value header, nil if header
+// is not defined
+func headerValues(ctx *Context, header string) []string {
+	seq := ctx.Request.Header.Values(header)
+	if len(seq) == 0 || seq[0] == "" {
+		return nil
 	}
+	return seq
 }
 
 func isHeaderExists(ctx *Context, header string) error {
 	opt := ctx.Request.Header.Get(string(header))
 	if opt == "" {
-		return ErrNoMatch
+		return headerNoMatch(header, reasonMissing)
 	}
 	return nil
 }
 
 func isHeaderEqString(ctx *Context, header string, value string) error {
 	opt := ctx.Request.Header.Get(string(header))
-	if opt == "" || !strings.HasPrefix(opt, value) {
-		return ErrNoMatch
+	if opt == "" {
+		return headerNoMatch(header, reasonMissing)
+	}
+
+	if !strings.HasPrefix(opt, value) {
+		return headerNoMatch(header, reasonMismatch)
 	}
 	return nil
 }
@@ -113,16 +136,16 @@ func isHeaderEqString(ctx *Context, header string, value string) error {
 func isHeaderEqInt(ctx *Context, header string, value int) error {
 	opt := ctx.Request.Header.Get(string(header))
 	if opt == "" {
-		return ErrNoMatch
+		return headerNoMatch(header, reasonMissing)
 	}
 
 	val, err := strconv.Atoi(opt)
 	if err != nil {
-		return ErrNoMatch
+		return headerNoMatch(header, reasonInvalid)
 	}
 
 	if val != value {
-		return ErrNoMatch
+		return headerNoMatch(header, reasonMismatch)
 	}
 
 	return nil
@@ -131,21 +154,42 @@ func isHeaderEqInt(ctx *Context, header string, value int) error {
 func isHeaderEqTime(ctx *Context, header string, value time.Time) error {
 	opt := ctx.Request.Header.Get(string(header))
 	if opt == "" {
-		return ErrNoMatch
+		return headerNoMatch(header, reasonMissing)
 	}
 
 	val, err := time.Parse(time.RFC1123, opt)
 	if err != nil {
-		return ErrNoMatch
+		return headerNoMatch(header, reasonInvalid)
 	}
 
 	if !val.Equal(value) {
-		return ErrNoMatch
+		return headerNoMatch(header, reasonMismatch)
 	}
 
 	return nil
 }
 
+// headerNoMatch reports the failure of header combinator. Failures of
+// content negotiation and credentials have own status codes.
+func headerNoMatch(header, reason string) error {
+	status := http.StatusBadRequest
+	switch {
+	case strings.EqualFold(header, "Content-Type"):
+		status = http.StatusUnsupportedMediaType
+	case strings.EqualFold(header, "Accept"):
+		status = http.StatusNotAcceptable
+	case strings.EqualFold(header, "Authorization"):
+		status = http.StatusUnauthorized
+	}
+
+	return NoMatch{
+		Status: status,
+		Source: "header",
+		Key:    header,
+		Reason: reason,
+	}
+}
+
 // Internal type
 type HeaderOf[T MatchableHeaderValues] string
 
@@ -177,12 +221,25 @@ func (h HeaderOf[T]) Is(value T) Endpoint {
 // To matches header value to the request context. It uses lens abstraction to
 // decode HTTP header into Golang type. The Endpoint causes no-match if header
 // value cannot be decoded to the target type. See optics.Lens type for details.
+// The missing header does not cause no-match if lens has default value.
 func (h HeaderOf[T]) To(lens Lens) Endpoint {
+	multi := multiOf(lens)
+	decoder := decoderOf(lens)
+	def := defaultOf(lens)
+
 	return func(ctx *Context) error {
-		if opt := ctx.Request.Header.Get(string(h)); opt != "" {
-			return ctx.Put(lens, opt)
+		seq := headerValues(ctx, string(h))
+		if seq == nil {
+			if ctx.putDefault(def) {
+				return nil
+			}
+			return headerNoMatch(string(h), reasonMissing)
+		}
+
+		if err := ctx.putValues(lens, decoder, multi, seq); err != nil {
+			return headerNoMatch(string(h), invalid(err))
 		}
-		return ErrNoMatch
+		return nil
 	}
 }
 
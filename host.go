@@ -0,0 +1,239 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fogfish/gouldian/v2/internal/optics"
+)
+
+/*
+Hosts is the routing table of virtual hosts, each host owns the trie.
+The request is routed to the host with exact name, host patterns are tried
+in the order of declaration, the host `*` matches any name.
+*/
+type Hosts struct {
+	exact    map[string]*VirtualHost
+	patterns []*VirtualHost
+	fallback *VirtualHost
+}
+
+/*
+VirtualHost binds host pattern with the routing table
+*/
+type VirtualHost struct {
+	pattern string
+	expr    *regexp.Regexp
+	lens    []Lens
+	optics  []optics.Lens
+	router  *Node
+}
+
+/*
+VHost binds host pattern with the routing table. The pattern is either
+exact name or sequence of labels with placeholders `:`. Each placeholder
+matches a single label and lifts its value to the context using
+corresponding lens.
+
+	µ.NewHosts(
+		µ.VHost(":.api.example.com", µ.NewRoutes( ... ), tenant),
+		µ.VHost("admin.example.com", µ.NewRoutes( ... )),
+	)
+
+Hosts are matched by name, the port of request is ignored. The pattern
+with port (e.g. `localhost:8080`) panics. IPv6 literal (e.g. `[::1]`) is
+the exact name, it has no placeholders.
+*/
+func VHost(pattern string, router *Node, lens ...Lens) *VirtualHost {
+	pattern = strings.ToLower(pattern)
+	vhost := &VirtualHost{pattern: pattern, lens: lens, router: router}
+
+	if hasPort(pattern) {
+		panic("host " + pattern + " defines port, hosts are matched by name only")
+	}
+
+	if strings.HasPrefix(pattern, "[") {
+		if len(lens) != 0 {
+			panic("host " + pattern + " is IPv6 literal, it has no placeholders")
+		}
+		return vhost
+	}
+
+	if n := strings.Count(pattern, ":"); n != len(lens) {
+		panic("host " + pattern + " requires " + strconv.Itoa(n) + " lenses")
+	}
+
+	if len(lens) != 0 {
+		vhost.optics = make([]optics.Lens, len(lens))
+		for i, l := range lens {
+			vhost.optics[i] = l
+		}
+
+		re := "^"
+		for i, literal := range strings.Split(pattern, ":") {
+			if i != 0 {
+				re += "([^.]+)"
+			}
+			re += regexp.QuoteMeta(literal)
+		}
+		vhost.expr = regexp.MustCompile(re + "$")
+	}
+
+	return vhost
+}
+
+// NewHosts creates new routing table of virtual hosts
+func NewHosts(seq ...*VirtualHost) *Hosts {
+	hosts := &Hosts{
+		exact:    make(map[string]*VirtualHost),
+		patterns: make([]*VirtualHost, 0),
+	}
+
+	for _, vhost := range seq {
+		switch {
+		case vhost.pattern == "*":
+			hosts.fallback = vhost
+		case vhost.expr == nil:
+			hosts.exact[vhost.pattern] = vhost
+		default:
+			hosts.patterns = append(hosts.patterns, vhost)
+		}
+	}
+
+	return hosts
+}
+
+// Endpoint converts routing table of virtual hosts to Endpoint
+func (hosts *Hosts) Endpoint() Endpoint {
+	return func(ctx *Context) error {
+		if ctx.Request == nil {
+			return ErrNoMatch
+		}
+
+		ctx.free()
+		host := hostname(ctx.Request.Host)
+		if vhost, has := hosts.exact[host]; has {
+			return vhost.router.route(ctx)
+		}
+
+		for _, vhost := range hosts.patterns {
+			if vhost.match(ctx, host) {
+				return vhost.router.route(ctx)
+			}
+		}
+
+		if hosts.fallback != nil {
+			return hosts.fallback.router.route(ctx)
+		}
+
+		return ErrNotFound
+	}
+}
+
+// match host name to pattern, lifts values of labels to the context
+func (vhost *VirtualHost) match(ctx *Context, host string) bool {
+	labels := vhost.expr.FindStringSubmatch(host)
+	if labels == nil {
+		return false
+	}
+
+	for i, lens := range vhost.optics {
+		if err := ctx.Put(lens, labels[i+1]); err != nil {
+			ctx.free()
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Routes lists every route registered at the routing table of virtual hosts.
+The list is ordered by host, path and HTTP verb.
+*/
+func (hosts *Hosts) Routes() []RouteInfo {
+	seq := make([]RouteInfo, 0)
+	for _, vhost := range hosts.vhosts() {
+		host := vhost.template()
+		for _, route := range vhost.router.Routes() {
+			route.Host = host
+			seq = append(seq, route)
+		}
+	}
+
+	sort.SliceStable(seq, func(i, j int) bool { return seq[i].Host < seq[j].Host })
+
+	return seq
+}
+
+func (hosts *Hosts) vhosts() []*VirtualHost {
+	seq := make([]*VirtualHost, 0, len(hosts.exact)+len(hosts.patterns)+1)
+	for _, vhost := range hosts.exact {
+		seq = append(seq, vhost)
+	}
+	seq = append(seq, hosts.patterns...)
+	if hosts.fallback != nil {
+		seq = append(seq, hosts.fallback)
+	}
+	return seq
+}
+
+// template of host pattern, placeholders are named after lens fields
+func (vhost *VirtualHost) template() string {
+	labels := strings.Split(vhost.pattern, ":")
+	for i, lens := range vhost.lens {
+		if lens.target != nil {
+			labels[i+1] = lens.field.Name + labels[i+1]
+		}
+	}
+	return strings.Join(labels, ":")
+}
+
+// hasPort checks if host pattern defines port, the colon is the port
+// separator if it follows IPv6 literal or it is followed by digits only
+func hasPort(pattern string) bool {
+	if i := strings.LastIndexByte(pattern, ']'); i != -1 {
+		return i != len(pattern)-1
+	}
+
+	i := strings.LastIndexByte(pattern, ':')
+	if i == -1 || i == len(pattern)-1 {
+		return false
+	}
+
+	for _, c := range pattern[i+1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hostname normalizes Host header: port and trailing dot are removed
+func hostname(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i > strings.LastIndexByte(host, ']') {
+		host = host[:i]
+	}
+
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
@@ -0,0 +1,126 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+func TestHosts(t *testing.T) {
+	type myT struct{ Tenant, ID string }
+	tenant, id := µ.Optics2[myT, string, string]("Tenant", "ID")
+
+	hosts := µ.NewHosts(
+		µ.VHost("admin.api.example.com",
+			µ.NewRoutes(µ.GET(µ.URI(µ.Path("users")), mock.Output(http.StatusOK, "admin"))),
+		),
+		µ.VHost(":.api.example.com",
+			µ.NewRoutes(µ.GET(µ.URI(µ.Path("users"), µ.Path(id)))),
+			tenant,
+		),
+		µ.VHost("*",
+			µ.NewRoutes(µ.GET(µ.URI(µ.Path("users")), mock.Output(http.StatusOK, "default"))),
+		),
+	)
+	foo := hosts.Endpoint()
+
+	t.Run("Exact", func(t *testing.T) {
+		req := mock.Input(mock.Host("Admin.API.example.com:8080"), mock.URL("/users"))
+		it.Then(t).Should(
+			it.Nil(mock.CheckOutput(foo(req), "admin")),
+		)
+	})
+
+	t.Run("Pattern", func(t *testing.T) {
+		var val myT
+		req := mock.Input(mock.Host("acme.api.example.com"), mock.URL("/users/1"))
+		it.Then(t).Should(
+			it.Nil(foo(req)),
+			it.Nil(µ.FromContext(req, &val)),
+			it.Equal(val.Tenant, "acme"),
+			it.Equal(val.ID, "1"),
+		)
+	})
+
+	t.Run("Label", func(t *testing.T) {
+		req := mock.Input(mock.Host("a.b.api.example.com"), mock.URL("/users"))
+		it.Then(t).Should(
+			it.Nil(mock.CheckOutput(foo(req), "default")),
+		)
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		req := mock.Input(mock.Host("example.com"), mock.URL("/users"))
+		it.Then(t).Should(
+			it.Nil(mock.CheckOutput(foo(req), "default")),
+		)
+	})
+
+	t.Run("Introspection", func(t *testing.T) {
+		routes := hosts.Routes()
+		it.Then(t).Should(
+			it.Equal(len(routes), 3),
+			it.Equal(routes[0].Host, "*"),
+			it.Equal(routes[1].Host, ":Tenant.api.example.com"),
+			it.Equal(routes[2].Host, "admin.api.example.com"),
+		)
+	})
+}
+
+func TestHostsNotFound(t *testing.T) {
+	foo := µ.NewHosts(
+		µ.VHost("example.com", µ.NewRoutes(µ.GET(µ.URI()))),
+	).Endpoint()
+
+	req := mock.Input(mock.Host("example.org"))
+	it.Then(t).Should(
+		it.Equal(foo(req), µ.ErrNotFound),
+	)
+}
+
+func TestHostsIPv6(t *testing.T) {
+	foo := µ.NewHosts(
+		µ.VHost("[::1]", µ.NewRoutes(µ.GET(µ.URI(), mock.Output(http.StatusOK, "ipv6")))),
+	).Endpoint()
+
+	req := mock.Input(mock.Host("[::1]:8080"))
+	it.Then(t).Should(
+		it.Nil(mock.CheckOutput(foo(req), "ipv6")),
+	)
+}
+
+func TestHostsWithPort(t *testing.T) {
+	for _, host := range []string{"localhost:8080", "[::1]:8080"} {
+		t.Run(host, func(t *testing.T) {
+			defer func() {
+				it.Then(t).ShouldNot(
+					it.Nil(recover()),
+				)
+			}()
+
+			µ.VHost(host, µ.NewRoutes())
+			t.Error("host with port is accepted")
+		})
+	}
+}
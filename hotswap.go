@@ -0,0 +1,125 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"sync/atomic"
+)
+
+/*
+HotSwap is the router handle that replaces the routing table at runtime.
+The swap is atomic and safe under concurrent requests, in-flight requests
+finish on the old table.
+
+	router := µ.NewHotSwap(µ.NewRoutes( ... ))
+	http.ListenAndServe(":8080", httpd.ServeRouter(router))
+
+	diff := router.Swap(µ.NewRoutes( ... ))
+*/
+type HotSwap struct {
+	table atomic.Pointer[table]
+}
+
+// table is the routing table with its endpoint
+type table struct {
+	router   Router
+	endpoint Endpoint
+}
+
+// NewHotSwap creates the router handle
+func NewHotSwap(router Router) *HotSwap {
+	h := &HotSwap{}
+	h.table.Store(&table{router: router, endpoint: router.Endpoint()})
+	return h
+}
+
+// Router returns current routing table
+func (h *HotSwap) Router() Router {
+	return h.table.Load().router
+}
+
+// Swap replaces the routing table, it returns difference between route sets
+func (h *HotSwap) Swap(router Router) RoutesDiff {
+	prev := h.table.Swap(&table{router: router, endpoint: router.Endpoint()})
+	return Diff(prev.router, router)
+}
+
+// Endpoint converts the router handle to Endpoint
+func (h *HotSwap) Endpoint() Endpoint {
+	return func(ctx *Context) error {
+		return h.table.Load().endpoint(ctx)
+	}
+}
+
+// Routes lists every route registered at current routing table
+func (h *HotSwap) Routes() []RouteInfo {
+	return routesOf(h.Router())
+}
+
+/*
+RoutesDiff is the difference between route sets of routing tables.
+*/
+type RoutesDiff struct {
+	Added   []RouteInfo `json:"added,omitempty"`
+	Removed []RouteInfo `json:"removed,omitempty"`
+}
+
+/*
+Diff compares route sets of routing tables, routes are identified by host,
+HTTP verb and path. Routers that do not support introspection (see Routes)
+have empty route set.
+*/
+func Diff(a, b Router) RoutesDiff {
+	seqA, seqB := routesOf(a), routesOf(b)
+	setA, setB := routeSet(seqA), routeSet(seqB)
+
+	diff := RoutesDiff{}
+	for _, route := range seqB {
+		if _, has := setA[route.key()]; !has {
+			diff.Added = append(diff.Added, route)
+		}
+	}
+
+	for _, route := range seqA {
+		if _, has := setB[route.key()]; !has {
+			diff.Removed = append(diff.Removed, route)
+		}
+	}
+
+	return diff
+}
+
+func routesOf(router Router) []RouteInfo {
+	if r, ok := router.(interface{ Routes() []RouteInfo }); ok {
+		return r.Routes()
+	}
+	return nil
+}
+
+func routeSet(seq []RouteInfo) map[string]struct{} {
+	set := make(map[string]struct{}, len(seq))
+	for _, route := range seq {
+		set[route.key()] = struct{}{}
+	}
+	return set
+}
+
+func (route RouteInfo) key() string {
+	return route.Host + " " + route.Method + " " + route.Path
+}
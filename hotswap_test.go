@@ -0,0 +1,89 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+func TestHotSwap(t *testing.T) {
+	router := µ.NewHotSwap(
+		µ.NewRoutes(
+			µ.GET(µ.URI(µ.Path("foo")), mock.Output(http.StatusOK, "v1")),
+			µ.GET(µ.URI(µ.Path("bar")), mock.Output(http.StatusOK, "v1")),
+		),
+	)
+	foo := router.Endpoint()
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckOutput(foo(mock.Input(mock.URL("/foo"))), "v1")),
+	)
+
+	diff := router.Swap(
+		µ.NewRoutes(
+			µ.GET(µ.URI(µ.Path("foo")), mock.Output(http.StatusOK, "v2")),
+			µ.POST(µ.URI(µ.Path("foo")), mock.Output(http.StatusOK, "v2")),
+		),
+	)
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckOutput(foo(mock.Input(mock.URL("/foo"))), "v2")),
+		it.Equal(foo(mock.Input(mock.URL("/bar"))), µ.ErrNotFound),
+		it.Equal(len(diff.Added), 1),
+		it.Equal(diff.Added[0].Method, "POST"),
+		it.Equal(diff.Added[0].Path, "/foo"),
+		it.Equal(len(diff.Removed), 1),
+		it.Equal(diff.Removed[0].Method, "GET"),
+		it.Equal(diff.Removed[0].Path, "/bar"),
+	)
+}
+
+func TestHotSwapInFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	router := µ.NewHotSwap(
+		µ.NewRoutes(
+			µ.GET(µ.URI(µ.Path("foo")),
+				func(*µ.Context) error {
+					close(started)
+					<-release
+					return nil
+				},
+				mock.Output(http.StatusOK, "v1"),
+			),
+		),
+	)
+	foo := router.Endpoint()
+
+	done := make(chan error)
+	go func() { done <- foo(mock.Input(mock.URL("/foo"))) }()
+
+	<-started
+	router.Swap(µ.NewRoutes(µ.GET(µ.URI(µ.Path("foo")), mock.Output(http.StatusOK, "v2"))))
+	close(release)
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckOutput(<-done, "v1")),
+		it.Nil(mock.CheckOutput(foo(mock.Input(mock.URL("/foo"))), "v2")),
+	)
+}
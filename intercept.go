@@ -0,0 +1,101 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+/*
+Interceptor wraps the endpoint. It runs code before and after the endpoint,
+observes the result (*Output, NoMatch or error) and might rewrite it:
+headers, status or body of *Output, or replace the result completely.
+
+	func ServerHeader(ctx *µ.Context, next µ.Endpoint) error {
+		err := next(ctx)
+		if out, ok := err.(*µ.Output); ok {
+			out.SetHeader("Server", "gouldian")
+		}
+		return err
+	}
+
+The interceptor that replaces *Output with other value is responsible
+to release the original one with Output.Free.
+*/
+type Interceptor func(ctx *Context, next Endpoint) error
+
+// Wrap the endpoint with interceptor
+func (f Interceptor) Wrap(next Endpoint) Endpoint {
+	return func(ctx *Context) error { return f(ctx, next) }
+}
+
+/*
+Intercept wraps endpoints of the route with interceptors. The first
+interceptor is the outermost one. The interceptor wraps each route of
+the group if it is applied to Mount.
+
+	µ.Intercept(
+		µ.GET(µ.URI(µ.Path("users")), ...),
+		ServerHeader,
+	)
+*/
+func Intercept(route Routable, seq ...Interceptor) Routable {
+	return func() Spec {
+		spec := route()
+		spec.Func = intercept(spec.Func, seq)
+		for i := range spec.Group {
+			spec.Group[i].Func = intercept(spec.Group[i].Func, seq)
+		}
+
+		return spec
+	}
+}
+
+/*
+InterceptRouter wraps the router with interceptors, they observe every
+request including one not matched by any route.
+*/
+func InterceptRouter(router Router, seq ...Interceptor) Router {
+	if len(seq) == 0 {
+		return router
+	}
+
+	return intercepted{Router: router, seq: seq}
+}
+
+type intercepted struct {
+	Router
+	seq []Interceptor
+}
+
+func (r intercepted) Endpoint() Endpoint {
+	return intercept(r.Router.Endpoint(), r.seq)
+}
+
+// Routes lists routes of the router, if it supports introspection
+func (r intercepted) Routes() []RouteInfo {
+	return routesOf(r.Router)
+}
+
+func intercept(endpoint Endpoint, seq []Interceptor) Endpoint {
+	if endpoint == nil {
+		return nil
+	}
+
+	for i := len(seq) - 1; i >= 0; i-- {
+		endpoint = seq[i].Wrap(endpoint)
+	}
+	return endpoint
+}
@@ -0,0 +1,131 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+func TestIntercept(t *testing.T) {
+	seq := []string{}
+	trace := func(id string) µ.Interceptor {
+		return func(ctx *µ.Context, next µ.Endpoint) error {
+			seq = append(seq, "before "+id)
+			err := next(ctx)
+			seq = append(seq, "after "+id)
+			return err
+		}
+	}
+
+	status := func(ctx *µ.Context, next µ.Endpoint) error {
+		err := next(ctx)
+		if out, ok := err.(*µ.Output); ok {
+			out.Status = http.StatusAccepted
+			out.SetHeader("X-Foo", "bar")
+		}
+		return err
+	}
+
+	foo := µ.NewRoutes(
+		µ.Intercept(
+			µ.GET(µ.URI(µ.Path("foo")), mock.Output(http.StatusOK, "foo")),
+			trace("a"), trace("b"), status,
+		),
+	).Endpoint()
+
+	err := foo(mock.Input(mock.URL("/foo")))
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(err, http.StatusAccepted)),
+		it.Equal(err.(*µ.Output).GetHeader("X-Foo"), "bar"),
+		it.Seq(seq).Equal("before a", "before b", "after b", "after a"),
+	)
+}
+
+func TestInterceptNoMatch(t *testing.T) {
+	var seen error
+	observe := func(ctx *µ.Context, next µ.Endpoint) error {
+		seen = next(ctx)
+		return seen
+	}
+
+	foo := µ.NewRoutes(
+		µ.Intercept(
+			µ.GET(µ.URI(µ.Path("foo")), µ.Header("X-Foo", "bar")),
+			observe,
+		),
+	).Endpoint()
+
+	it.Then(t).Should(
+		it.Equal(foo(mock.Input(mock.URL("/foo"))), errNoFoo),
+		it.Equal(seen, errNoFoo),
+	)
+}
+
+func TestInterceptGroup(t *testing.T) {
+	cnt := 0
+	count := func(ctx *µ.Context, next µ.Endpoint) error {
+		cnt++
+		return next(ctx)
+	}
+
+	foo := µ.NewRoutes(
+		µ.Intercept(
+			µ.Mount(
+				µ.Route(µ.URI(µ.Path("api"))),
+				µ.GET(µ.URI(µ.Path("foo"))),
+				µ.GET(µ.URI(µ.Path("bar"))),
+			),
+			count,
+		),
+		µ.GET(µ.URI(µ.Path("baz"))),
+	).Endpoint()
+
+	for _, url := range []string{"/api/foo", "/api/bar", "/baz"} {
+		it.Then(t).Should(
+			it.Nil(foo(mock.Input(mock.URL(url)))),
+		)
+	}
+
+	it.Then(t).Should(
+		it.Equal(cnt, 2),
+	)
+}
+
+func TestInterceptRouter(t *testing.T) {
+	notFound := func(ctx *µ.Context, next µ.Endpoint) error {
+		err := next(ctx)
+		if err == µ.ErrNotFound {
+			return µ.NewOutput(http.StatusTeapot)
+		}
+		return err
+	}
+
+	routes := µ.NewRoutes(µ.GET(µ.URI(µ.Path("foo"))))
+	router := µ.InterceptRouter(routes, notFound)
+
+	it.Then(t).Should(
+		it.Nil(router.Endpoint()(mock.Input(mock.URL("/foo")))),
+		it.Nil(mock.CheckStatusCode(router.Endpoint()(mock.Input(mock.URL("/bar"))), http.StatusTeapot)),
+	)
+}
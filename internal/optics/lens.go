@@ -43,21 +43,53 @@ type Value struct {
 // Lens is composable setter of Value to "some" struct
 type Lens interface {
 	FromString(string) (Value, error)
+	ToString(any) (string, error)
 	Put(any, Value) error
 }
 
+// Decoder is implemented by lenses that focus on types other than Value,
+// the term is decoded once, the decoded value is carried by Morphism
+type Decoder interface {
+	Decode(string) (Decoded, error)
+}
+
+// Decoded is the value of term decoded by Decoder
+type Decoded interface {
+	Inject(any) error
+}
+
+// decoded value of type A, it is injected by reflector
+type decoded[A any] struct {
+	optics.Reflector[A]
+	value A
+}
+
+func (d decoded[A]) Inject(s any) error {
+	d.Reflector.Putt(s, d.value)
+	return nil
+}
+
 // Morphism is product of Lens and Value
 type Morphism struct {
 	Lens
 	Value
+	Decoded Decoded // the value decoded by Decoder, it is injected instead of Value
+	Default bool    // the value is not supplied by request, it is defined by lens
 }
 
 // Morphisms is collection of lenses and values to be applied for object
 type Morphisms []Morphism
 
 func Morph[S any](m Morphisms, s *S) error {
-	for _, arrow := range m {
-		if err := arrow.Lens.Put(s, arrow.Value); err != nil {
+	for i := range m {
+		if m[i].Decoded != nil {
+			if err := m[i].Decoded.Inject(s); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := m[i].Lens.Put(s, m[i].Value); err != nil {
 			return err
 		}
 	}
@@ -76,6 +108,11 @@ func (l *lensString[S, A]) Put(s any, a Value) error {
 	return nil
 }
 
+func (l *lensString[S, A]) ToString(s any) (string, error) {
+	t := l.Reflector.Gett(s)
+	return *(*string)(unsafe.Pointer(uintptr(unsafe.Pointer(&t)))), nil
+}
+
 func (l *lensString[S, A]) FromString(a string) (Value, error) {
 	return Value{String: a}, nil
 }
@@ -90,6 +127,14 @@ func (l *lensStringPointer[S, A]) Put(s any, a Value) error {
 	return nil
 }
 
+func (l *lensStringPointer[S, A]) ToString(s any) (string, error) {
+	t := l.Reflector.Gett(s)
+	if v := *(**string)(unsafe.Pointer(uintptr(unsafe.Pointer(&t)))); v != nil {
+		return *v, nil
+	}
+	return "", nil
+}
+
 func (l *lensStringPointer[S, A]) FromString(a string) (Value, error) {
 	return Value{String: a}, nil
 }
@@ -104,6 +149,11 @@ func (l *lensNumber[S, A]) Put(s any, a Value) error {
 	return nil
 }
 
+func (l *lensNumber[S, A]) ToString(s any) (string, error) {
+	t := l.Reflector.Gett(s)
+	return strconv.Itoa(*(*int)(unsafe.Pointer(uintptr(unsafe.Pointer(&t))))), nil
+}
+
 func (l *lensNumber[S, A]) FromString(a string) (Value, error) {
 	val, err := strconv.Atoi(a)
 	if err != nil {
@@ -123,6 +173,14 @@ func (l *lensNumberPointer[S, A]) Put(s any, a Value) error {
 	return nil
 }
 
+func (l *lensNumberPointer[S, A]) ToString(s any) (string, error) {
+	t := l.Reflector.Gett(s)
+	if v := *(**int)(unsafe.Pointer(uintptr(unsafe.Pointer(&t)))); v != nil {
+		return strconv.Itoa(*v), nil
+	}
+	return "", nil
+}
+
 func (l *lensNumberPointer[S, A]) FromString(a string) (Value, error) {
 	val, err := strconv.Atoi(a)
 	if err != nil {
@@ -142,6 +200,11 @@ func (l *lensDouble[S, A]) Put(s any, a Value) error {
 	return nil
 }
 
+func (l *lensDouble[S, A]) ToString(s any) (string, error) {
+	t := l.Reflector.Gett(s)
+	return strconv.FormatFloat(*(*float64)(unsafe.Pointer(uintptr(unsafe.Pointer(&t)))), 'f', -1, 64), nil
+}
+
 func (l *lensDouble[S, A]) FromString(a string) (Value, error) {
 	val, err := strconv.ParseFloat(a, 64)
 	if err != nil {
@@ -161,6 +224,14 @@ func (l *lensDoublePointer[S, A]) Put(s any, a Value) error {
 	return nil
 }
 
+func (l *lensDoublePointer[S, A]) ToString(s any) (string, error) {
+	t := l.Reflector.Gett(s)
+	if v := *(**float64)(unsafe.Pointer(uintptr(unsafe.Pointer(&t)))); v != nil {
+		return strconv.FormatFloat(*v, 'f', -1, 64), nil
+	}
+	return "", nil
+}
+
 func (l *lensDoublePointer[S, A]) FromString(a string) (Value, error) {
 	val, err := strconv.ParseFloat(a, 64)
 	if err != nil {
@@ -183,6 +254,10 @@ func (l *lensParser[S]) Put(s any, a Value) error {
 	}
 }
 
+func (l *lensParser[S]) ToString(s any) (string, error) {
+	return l.Reflector.Gett(s), nil
+}
+
 func (l *lensParser[S]) FromString(a string) (Value, error) {
 	return Value{String: a}, nil
 }
@@ -243,6 +318,14 @@ func (lens *lensStructForm[A]) Gett(s any) string {
 func NewLens[S, A any](fln func(t hseq.Type[S]) optics.Lens[S, A]) func(t hseq.Type[S]) Lens {
 	return func(t hseq.Type[S]) Lens {
 		ln := fln(t)
+		if isTextUnmarshaler(t.PureType) {
+			return newLensText(t, ln.(optics.Reflector[A]))
+		}
+
+		if lens, ok := newLensScalar(t, ln.(optics.Reflector[A])); ok {
+			return lens
+		}
+
 		switch t.PureType.Kind() {
 		case reflect.String:
 			if t.StructField.Type.Kind() == reflect.Pointer {
@@ -259,6 +342,20 @@ func NewLens[S, A any](fln func(t hseq.Type[S]) optics.Lens[S, A]) func(t hseq.T
 				return &lensDoublePointer[S, A]{ln.(optics.Reflector[A])}
 			}
 			return &lensDouble[S, A]{ln.(optics.Reflector[A])}
+		case reflect.Slice:
+			switch t.Tag.Get("content") {
+			case "json", "application/json":
+				return &lensParser[S]{newLensStructJSON(ln.(optics.Reflector[A]))}
+			default:
+				return newLensSlice(t, ln.(optics.Reflector[A]))
+			}
+		case reflect.Map:
+			switch t.Tag.Get("content") {
+			case "json", "application/json":
+				return &lensParser[S]{newLensStructJSON(ln.(optics.Reflector[A]))}
+			default:
+				return newLensMap(t, ln.(optics.Reflector[A]))
+			}
 		case reflect.Struct:
 			switch t.Tag.Get("content") {
 			case "form":
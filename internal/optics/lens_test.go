@@ -19,7 +19,12 @@
 package optics_test
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fogfish/golem/hseq"
 	lenses "github.com/fogfish/golem/optics"
@@ -50,11 +55,17 @@ func structTest[A any](t *testing.T, given string, expect A) {
 		{Lens: b, Value: y},
 	}
 	e := optics.Morph(m, &v)
+	sa, ea := a.ToString(&v)
+	sb, eb := b.ToString(&v)
 
 	it.Then(t).Should(
 		it.Nil(e),
 		it.Equiv(v.A, expect),
 		it.Equiv(*v.B, expect),
+		it.Nil(ea),
+		it.Nil(eb),
+		it.Equal(sa, given),
+		it.Equal(sb, given),
 	)
 }
 
@@ -105,6 +116,83 @@ func TestLensStructFloatFail(t *testing.T) {
 	)
 }
 
+func TestLensStructScalar(t *testing.T) {
+	type Bool bool
+	type Level int8
+
+	structTest[bool](t, "true", true)
+	structTest[Bool](t, "false", false)
+	structTest[int8](t, "-100", -100)
+	structTest[Level](t, "7", 7)
+	structTest[int16](t, "-30000", -30000)
+	structTest[int32](t, "-2000000000", -2000000000)
+	structTest[int64](t, "-9000000000000000000", -9000000000000000000)
+	structTest[uint](t, "100", 100)
+	structTest[uint8](t, "255", 255)
+	structTest[uint16](t, "65535", 65535)
+	structTest[uint32](t, "4000000000", 4000000000)
+	structTest[uint64](t, "18000000000000000000", 18000000000000000000)
+	structTest[float32](t, "100.5", 100.5)
+	structTest[time.Duration](t, "1m30s", 90*time.Second)
+}
+
+func TestLensStructScalarFail(t *testing.T) {
+	//lint:ignore U1000 type is used but not instantiated
+	type T struct {
+		A bool
+		B int8
+		C uint16
+		D time.Duration
+	}
+	a, b, c, d := hseq.FMap4(
+		hseq.New[T]("A", "B", "C", "D"),
+		optics.NewLens(lenses.NewLens[T, bool]),
+		optics.NewLens(lenses.NewLens[T, int8]),
+		optics.NewLens(lenses.NewLens[T, uint16]),
+		optics.NewLens(lenses.NewLens[T, time.Duration]),
+	)
+
+	for lens, val := range map[optics.Lens]string{a: "abc", b: "128", c: "-1", d: "1 day"} {
+		_, err := lens.FromString(val)
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		)
+	}
+}
+
+type textT struct{ Scheme, Path string }
+
+func (t *textT) UnmarshalText(b []byte) error {
+	scheme, path, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return fmt.Errorf("invalid %s", b)
+	}
+	t.Scheme, t.Path = scheme, path
+	return nil
+}
+
+func (t textT) MarshalText() ([]byte, error) {
+	return []byte(t.Scheme + ":" + t.Path), nil
+}
+
+func TestLensStructText(t *testing.T) {
+	structTest[textT](t, "urn:a:b", textT{Scheme: "urn", Path: "a:b"})
+	structTest[time.Time](t, "2024-01-02T03:04:05Z", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+}
+
+func TestLensStructTextFail(t *testing.T) {
+	//lint:ignore U1000 type is used but not instantiated
+	type T struct{ A *textT }
+	a := hseq.FMap1(
+		hseq.New[T]("A"),
+		optics.NewLens(lenses.NewLens[T, *textT]),
+	)
+	_, err := a.FromString("abc")
+	it.Then(t).ShouldNot(
+		it.Nil(err),
+	)
+}
+
 func TestLensStructJSON(t *testing.T) {
 	type J struct {
 		X string `json:"x"`
@@ -539,3 +627,285 @@ func TestLenses9(t *testing.T) {
 		it.Equal(v.I, "i"),
 	)
 }
+
+func TestLensStructSlice(t *testing.T) {
+	type Level int8
+
+	structSliceTest[string](t, "a,b,c", []string{"a", "b", "c"})
+	structSliceTest[int](t, "1,2,3", []int{1, 2, 3})
+	structSliceTest[Level](t, "1,-2", []Level{1, -2})
+	structSliceTest[float64](t, "1.5,2", []float64{1.5, 2})
+	structSliceTest[bool](t, "true,false", []bool{true, false})
+	structSliceTest[time.Duration](t, "1s,1m0s", []time.Duration{time.Second, time.Minute})
+	structSliceTest[textT](t, "urn:a,urn:b", []textT{{"urn", "a"}, {"urn", "b"}})
+}
+
+func structSliceTest[A any](t *testing.T, given string, expect []A) {
+	t.Helper()
+
+	type T struct {
+		A []A
+		B []A `sep:";"`
+	}
+
+	a, b := hseq.FMap2(
+		hseq.New[T]("A", "B"),
+		optics.NewLens(lenses.NewLens[T, []A]),
+		optics.NewLens(lenses.NewLens[T, []A]),
+	)
+	x, _ := a.FromString(given)
+	y, _ := b.FromString(strings.ReplaceAll(given, ",", "; "))
+
+	var v T
+	e := optics.Morph(optics.Morphisms{{Lens: a, Value: x}, {Lens: b, Value: y}}, &v)
+	sa, ea := a.ToString(&v)
+	sb, eb := b.ToString(&v)
+
+	it.Then(t).Should(
+		it.Nil(e),
+		it.Equiv(v.A, expect),
+		it.Equiv(v.B, expect),
+		it.Nil(ea),
+		it.Nil(eb),
+		it.Equal(sa, given),
+		it.Equal(sb, strings.ReplaceAll(given, ",", ";")),
+	)
+}
+
+func TestLensStructSliceFromStrings(t *testing.T) {
+	type T struct {
+		A []string
+		B []string `sep:";"`
+		C []string
+	}
+
+	a, b, c := hseq.FMap3(
+		hseq.New[T]("A", "B", "C"),
+		optics.NewLens(lenses.NewLens[T, []string]),
+		optics.NewLens(lenses.NewLens[T, []string]),
+		optics.NewLens(lenses.NewLens[T, []string]),
+	)
+
+	x, ex := a.(optics.Multi).FromStrings([]string{"a", "b, c"})
+	y, ey := b.(optics.Multi).FromStrings([]string{"Smith, John; Doe", "Roe"})
+	z, ez := c.(optics.Multi).FromStrings([]string{"a, b", "c"})
+
+	var v T
+	e := optics.Morph(optics.Morphisms{{Lens: a, Decoded: x}, {Lens: b, Decoded: y}, {Lens: c, Decoded: z}}, &v)
+	it.Then(t).Should(
+		it.Nil(ex),
+		it.Nil(ey),
+		it.Nil(ez),
+		it.Nil(e),
+		it.Equiv(v.A, []string{"a", "b", "c"}),
+		it.Equiv(v.B, []string{"Smith, John", "Doe", "Roe"}),
+		it.Equiv(v.C, []string{"a", "b", "c"}),
+	)
+}
+
+func TestLensStructSliceFail(t *testing.T) {
+	//lint:ignore U1000 type is used but not instantiated
+	type T struct{ A []int }
+	a := hseq.FMap1(
+		hseq.New[T]("A"),
+		optics.NewLens(lenses.NewLens[T, []int]),
+	)
+	_, err := a.FromString("1,x,3")
+
+	var elem optics.ElementError
+	it.Then(t).Should(
+		it.True(errors.As(err, &elem)),
+		it.Equal(elem.Index, 1),
+	)
+}
+
+func TestLensReflect(t *testing.T) {
+	type Level int8
+	type Item struct {
+		ID string `json:"id" form:"id"`
+	}
+
+	reflectTest[string](t, "", "a", "a")
+	reflectTest[int](t, "", "10", 10)
+	reflectTest[Level](t, "", "-2", Level(-2))
+	reflectTest[float64](t, "", "1.5", 1.5)
+	reflectTest[bool](t, "", "true", true)
+	reflectTest[time.Duration](t, "", "1m0s", time.Minute)
+	reflectTest[textT](t, "", "urn:a", textT{"urn", "a"})
+	reflectTest[[]int](t, "", "1,2", []int{1, 2})
+	reflectTest[[]int](t, "json", "[1,2]", []int{1, 2})
+	reflectTest[Item](t, "", `{"id":"a"}`, Item{ID: "a"})
+	reflectTest[Item](t, "form", "id=a", Item{ID: "a"})
+}
+
+func reflectTest[A any](t *testing.T, content, given string, expect A) {
+	t.Helper()
+
+	type T struct {
+		A A
+		B *A
+	}
+
+	seq := hseq.New[T]()
+	a := optics.NewLensReflect(seq[0], content)
+	x, ex := a.FromString(given)
+
+	var v T
+	e := optics.Morph(optics.Morphisms{{Lens: a, Value: x}}, &v)
+	s, es := a.ToString(&v)
+
+	it.Then(t).Should(
+		it.Nil(ex),
+		it.Nil(e),
+		it.Nil(es),
+		it.Equiv(v.A, expect),
+		it.Equal(s, given),
+	)
+
+	// pointers to slice and map are not supported
+	if k := reflect.TypeOf(expect).Kind(); k != reflect.Slice && k != reflect.Map {
+		b := optics.NewLensReflect(seq[1], content)
+		y, ey := b.FromString(given)
+		e := optics.Morph(optics.Morphisms{{Lens: b, Value: y}}, &v)
+		s, es := b.ToString(&v)
+
+		it.Then(t).Should(
+			it.Nil(ey),
+			it.Nil(e),
+			it.Nil(es),
+			it.Equiv(*v.B, expect),
+			it.Equal(s, given),
+		)
+	}
+}
+
+func TestLensReflectFail(t *testing.T) {
+	type T struct {
+		A int
+		B []int
+		C chan int
+	}
+
+	seq := hseq.New[T]()
+	a := optics.NewLensReflect(seq[0], "")
+	b := optics.NewLensReflect(seq[1], "")
+	_, ea := a.FromString("abc")
+	_, eb := b.FromString("1,abc")
+
+	var elem optics.ElementError
+	it.Then(t).Should(
+		it.Fail(func() error { return ea }),
+		it.True(errors.As(eb, &elem)),
+		it.Equal(elem.Index, 1),
+	)
+
+	_, isMulti := b.(optics.Multi)
+	_, isScalar := a.(optics.Multi)
+	it.Then(t).Should(
+		it.True(isMulti),
+		it.True(!isScalar),
+		it.Fail(func() { optics.NewLensReflect(seq[2], "") }),
+	)
+}
+
+func TestLensStructMap(t *testing.T) {
+	type T struct {
+		A map[string]string
+		B map[string][]int
+		C map[string]int `content:"json"`
+	}
+
+	a, b, c := hseq.FMap3(
+		hseq.New[T]("A", "B", "C"),
+		optics.NewLens(lenses.NewLens[T, map[string]string]),
+		optics.NewLens(lenses.NewLens[T, map[string][]int]),
+		optics.NewLens(lenses.NewLens[T, map[string]int]),
+	)
+	x, ex := a.FromString("a=1&b=2")
+	y, ey := b.FromString("a=1&a=2&b=3")
+	z, ez := c.FromString(`{"a":1}`)
+
+	var v T
+	e := optics.Morph(optics.Morphisms{{Lens: a, Value: x}, {Lens: b, Value: y}, {Lens: c, Value: z}}, &v)
+	sa, _ := a.ToString(&v)
+	sb, _ := b.ToString(&v)
+
+	it.Then(t).Should(
+		it.Nil(ex),
+		it.Nil(ey),
+		it.Nil(ez),
+		it.Nil(e),
+		it.Equiv(v.A, map[string]string{"a": "1", "b": "2"}),
+		it.Equiv(v.B, map[string][]int{"a": {1, 2}, "b": {3}}),
+		it.Equiv(v.C, map[string]int{"a": 1}),
+		it.Equal(sa, "a=1&b=2"),
+		it.Equal(sb, "a=1&a=2&b=3"),
+	)
+}
+
+func TestLensStructMapFail(t *testing.T) {
+	type T struct {
+		A map[string][]int
+		B *map[string]string
+		C map[int]string
+	}
+
+	seq := hseq.New[T]()
+	a := optics.NewLens(lenses.NewLens[T, map[string][]int])(seq[0])
+	_, err := a.FromString("a=1&a=x")
+
+	var elem optics.ElementError
+	it.Then(t).Should(
+		it.True(errors.As(err, &elem)),
+		it.Equal(elem.Index, 1),
+		it.Fail(func() { optics.NewLens(lenses.NewLens[T, *map[string]string])(seq[1]) }),
+		it.Fail(func() { optics.NewLens(lenses.NewLens[T, map[int]string])(seq[2]) }),
+		it.Fail(func() { optics.NewLensReflect(seq[2], "") }),
+	)
+}
+
+func TestLensReflectMap(t *testing.T) {
+	reflectTest[map[string]string](t, "", "a=1&b=2", map[string]string{"a": "1", "b": "2"})
+	reflectTest[map[string]int](t, "json", `{"a":1}`, map[string]int{"a": 1})
+}
+
+func TestLensDecoder(t *testing.T) {
+	type T struct {
+		A textT
+		B []int
+		C map[string]string
+		D *textT
+	}
+
+	seq := hseq.New[T]()
+	a, b, c := hseq.FMap3(
+		seq,
+		optics.NewLens(lenses.NewLens[T, textT]),
+		optics.NewLens(lenses.NewLens[T, []int]),
+		optics.NewLens(lenses.NewLens[T, map[string]string]),
+	)
+	d := optics.NewLensReflect(seq[3], "")
+
+	x, ex := a.(optics.Decoder).Decode("urn:a")
+	y, ey := b.(optics.Decoder).Decode("1,2")
+	z, ez := c.(optics.Decoder).Decode("a=1")
+	w, ew := d.(optics.Decoder).Decode("urn:b")
+	_, ef := b.(optics.Decoder).Decode("1,x")
+
+	var v T
+	e := optics.Morph(optics.Morphisms{{Lens: a, Decoded: x}, {Lens: b, Decoded: y}, {Lens: c, Decoded: z}, {Lens: d, Decoded: w}}, &v)
+
+	it.Then(t).Should(
+		it.Nil(ex),
+		it.Nil(ey),
+		it.Nil(ez),
+		it.Nil(ew),
+		it.Nil(e),
+		it.Equiv(v.A, textT{"urn", "a"}),
+		it.Equiv(v.B, []int{1, 2}),
+		it.Equiv(v.C, map[string]string{"a": "1"}),
+		it.Equiv(*v.D, textT{"urn", "b"}),
+	).ShouldNot(
+		it.Nil(ef),
+	)
+}
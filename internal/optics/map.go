@@ -0,0 +1,149 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package optics
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/fogfish/golem/hseq"
+	"github.com/fogfish/golem/optics"
+)
+
+// Lens to deal with maps, the value is url encoded query
+// (e.g. a=1&b=2). The key of map is string, the value is either scalar or
+// slice of scalars, the slice collects every value of repeated key.
+type lensMap[A any] struct {
+	optics.Reflector[A]
+	decode func(string) (reflect.Value, error)
+	encode func(reflect.Value) string
+}
+
+func newLensMap[S, A any](t hseq.Type[S], r optics.Reflector[A]) Lens {
+	if t.StructField.Type.Kind() == reflect.Pointer {
+		panic(fmt.Errorf("type %v is not supported", t.Type))
+	}
+
+	decode, encode := mapCodec(t.PureType)
+	if decode == nil {
+		panic(fmt.Errorf("type %v is not supported", t.Type))
+	}
+
+	return &lensMap[A]{Reflector: r, decode: decode, encode: encode}
+}
+
+func (l *lensMap[A]) Put(s any, a Value) error {
+	m, err := l.decode(a.String)
+	if err != nil {
+		return err
+	}
+
+	l.Reflector.Putt(s, m.Interface().(A))
+	return nil
+}
+
+func (l *lensMap[A]) ToString(s any) (string, error) {
+	return l.encode(reflect.ValueOf(l.Reflector.Gett(s))), nil
+}
+
+// FromString checks the query, it is kept as-is and parsed by Put
+func (l *lensMap[A]) FromString(a string) (Value, error) {
+	if _, err := l.decode(a); err != nil {
+		return Value{}, err
+	}
+	return Value{String: a}, nil
+}
+
+func (l *lensMap[A]) Decode(a string) (Decoded, error) {
+	m, err := l.decode(a)
+	if err != nil {
+		return nil, err
+	}
+	return decoded[A]{Reflector: l.Reflector, value: m.Interface().(A)}, nil
+}
+
+// mapCodec decodes url encoded query into map of type t
+func mapCodec(t reflect.Type) (func(string) (reflect.Value, error), func(reflect.Value) string) {
+	if t.Key().Kind() != reflect.String {
+		return nil, nil
+	}
+
+	elem := t.Elem()
+	multi := elem.Kind() == reflect.Slice && !isTextUnmarshaler(elem)
+	if multi {
+		elem = elem.Elem()
+	}
+
+	decode, encode := elementCodec(elem)
+	if decode == nil {
+		return nil, nil
+	}
+
+	decodeKey := func(key string, seq []string) (reflect.Value, error) {
+		if !multi {
+			v, err := decode(seq[0])
+			if err != nil {
+				return v, fmt.Errorf("key %s: %w", key, err)
+			}
+			return v, nil
+		}
+
+		slice := reflect.MakeSlice(t.Elem(), len(seq), len(seq))
+		for i, x := range seq {
+			v, err := decode(x)
+			if err != nil {
+				return slice, fmt.Errorf("key %s: %w", key, ElementError{Index: i, Err: err})
+			}
+			slice.Index(i).Set(v)
+		}
+		return slice, nil
+	}
+
+	return func(s string) (reflect.Value, error) {
+			query, err := url.ParseQuery(s)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			m := reflect.MakeMapWithSize(t, len(query))
+			for key, seq := range query {
+				v, err := decodeKey(key, seq)
+				if err != nil {
+					return m, err
+				}
+				m.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), v)
+			}
+			return m, nil
+		}, func(m reflect.Value) string {
+			query := url.Values{}
+			iter := m.MapRange()
+			for iter.Next() {
+				key, val := iter.Key().String(), iter.Value()
+				if !multi {
+					query.Set(key, encode(val))
+					continue
+				}
+				for i := 0; i < val.Len(); i++ {
+					query.Add(key, encode(val.Index(i)))
+				}
+			}
+			return query.Encode()
+		}
+}
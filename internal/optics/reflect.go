@@ -0,0 +1,195 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package optics
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/ajg/form"
+	"github.com/fogfish/golem/hseq"
+)
+
+// Lens to deal with field of struct, the type of field is resolved at
+// run-time. It supports same types as NewLens. The term is decoded once
+// by Decode, FromString keeps the term that is decoded by Put.
+type lensReflect struct {
+	target  reflect.Type // pointer to struct
+	field   reflect.Type
+	offset  uintptr
+	pointer bool
+	decode  func(string) (reflect.Value, error)
+	encode  func(reflect.Value) string
+}
+
+// Lens to deal with slice field, it accepts values of repeated terms
+type lensReflectSlice struct {
+	*lensReflect
+	codec sliceCodec
+}
+
+func (l lensReflectSlice) FromStrings(seq []string) (Decoded, error) {
+	v, err := l.codec.decodeAll(l.codec.elements(seq))
+	if err != nil {
+		return nil, err
+	}
+
+	return reflected{lens: l.lensReflect, value: v}, nil
+}
+
+// decoded value of the field
+type reflected struct {
+	lens  *lensReflect
+	value reflect.Value
+}
+
+func (d reflected) Inject(s any) error {
+	d.lens.value(s).Set(d.value)
+	return nil
+}
+
+// NewLensReflect creates lens instance for the field, the type of field is
+// not known at compile time. The content defines the codec of struct and map
+// types (json or form), the `content` tag is not used.
+func NewLensReflect[S any](t hseq.Type[S], content string) Lens {
+	lens := &lensReflect{
+		target:  reflect.TypeOf(new(S)),
+		field:   t.StructField.Type,
+		offset:  t.Offset + t.RootOffs,
+		pointer: t.StructField.Type.Kind() == reflect.Pointer,
+	}
+
+	switch {
+	case isTextUnmarshaler(t.PureType):
+		lens.decode, lens.encode = elementCodec(t.PureType)
+	case t.PureType.Kind() == reflect.Slice && !isContentJSON(content):
+		if lens.pointer {
+			panic(fmt.Errorf("type %v is not supported", t.Type))
+		}
+		codec := newSliceCodec(t.StructField)
+		lens.decode, lens.encode = codec.split, codec.join
+		return lensReflectSlice{lensReflect: lens, codec: codec}
+	case t.PureType.Kind() == reflect.Map && !isContentJSON(content):
+		if lens.pointer {
+			panic(fmt.Errorf("type %v is not supported", t.Type))
+		}
+		lens.decode, lens.encode = mapCodec(t.PureType)
+	case t.PureType.Kind() == reflect.Struct || t.PureType.Kind() == reflect.Slice || t.PureType.Kind() == reflect.Map:
+		lens.decode, lens.encode = contentCodec(t.PureType, content)
+	default:
+		lens.decode, lens.encode = elementCodec(t.PureType)
+	}
+
+	if lens.decode == nil {
+		panic(fmt.Errorf("type %v is not supported", t.Type))
+	}
+
+	return lens
+}
+
+func isContentJSON(content string) bool {
+	return content == "json" || content == "application/json"
+}
+
+// contentCodec of struct type, json is default one
+func contentCodec(t reflect.Type, content string) (func(string) (reflect.Value, error), func(reflect.Value) string) {
+	switch content {
+	case "form", "application/x-www-form-urlencoded":
+		return func(s string) (reflect.Value, error) {
+				v := reflect.New(t)
+				err := form.DecodeString(v.Interface(), s)
+				return v.Elem(), err
+			}, func(v reflect.Value) string {
+				s, err := form.EncodeToString(v.Interface())
+				if err != nil {
+					panic(err)
+				}
+				return s
+			}
+	default:
+		return func(s string) (reflect.Value, error) {
+				v := reflect.New(t)
+				err := json.Unmarshal([]byte(s), v.Interface())
+				return v.Elem(), err
+			}, func(v reflect.Value) string {
+				b, err := json.Marshal(v.Interface())
+				if err != nil {
+					panic(err)
+				}
+				return string(b)
+			}
+	}
+}
+
+// value of the field
+func (l *lensReflect) value(s any) reflect.Value {
+	v := reflect.ValueOf(s)
+	if v.Type() != l.target {
+		panic(fmt.Errorf("invalid type %T passed to lens of %v", s, l.target))
+	}
+
+	return reflect.NewAt(l.field, unsafe.Add(v.UnsafePointer(), l.offset)).Elem()
+}
+
+func (l *lensReflect) Put(s any, a Value) error {
+	d, err := l.Decode(a.String)
+	if err != nil {
+		return err
+	}
+
+	return d.Inject(s)
+}
+
+func (l *lensReflect) Decode(a string) (Decoded, error) {
+	v, err := l.decode(a)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.pointer {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+
+	return reflected{lens: l, value: v}, nil
+}
+
+func (l *lensReflect) ToString(s any) (string, error) {
+	v := l.value(s)
+	if l.pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	return l.encode(v), nil
+}
+
+// FromString keeps the term as-is, it is checked so that invalid default
+// value is reported early
+func (l *lensReflect) FromString(a string) (Value, error) {
+	if _, err := l.decode(a); err != nil {
+		return Value{}, err
+	}
+	return Value{String: a}, nil
+}
@@ -0,0 +1,259 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package optics
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/fogfish/golem/hseq"
+	"github.com/fogfish/golem/optics"
+)
+
+var (
+	typeDuration        = reflect.TypeOf(time.Duration(0))
+	typeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// codec of scalar type T, the type is encoded as Value
+type codec[T any] struct {
+	decode func(string) (Value, error)
+	encode func(T) string
+	value  func(Value) T
+}
+
+// newLensScalar creates lens for scalar kinds other than string, int and float64
+func newLensScalar[S, A any](t hseq.Type[S], r optics.Reflector[A]) (Lens, bool) {
+	switch {
+	case t.PureType == typeDuration:
+		return scalar(t, r, codec[int64]{
+			decode: func(s string) (Value, error) {
+				d, err := time.ParseDuration(s)
+				return Value{Number: int(d)}, err
+			},
+			encode: func(v int64) string { return time.Duration(v).String() },
+			value:  func(v Value) int64 { return int64(v.Number) },
+		}), true
+	}
+
+	switch t.PureType.Kind() {
+	case reflect.Bool:
+		return scalar(t, r, codec[bool]{
+			decode: func(s string) (Value, error) {
+				v, err := strconv.ParseBool(s)
+				if v {
+					return Value{Number: 1}, err
+				}
+				return Value{}, err
+			},
+			encode: strconv.FormatBool,
+			value:  func(v Value) bool { return v.Number != 0 },
+		}), true
+	case reflect.Int8:
+		return scalar(t, r, codecInt[int8](8)), true
+	case reflect.Int16:
+		return scalar(t, r, codecInt[int16](16)), true
+	case reflect.Int32:
+		return scalar(t, r, codecInt[int32](32)), true
+	case reflect.Int64:
+		return scalar(t, r, codecInt[int64](64)), true
+	case reflect.Uint:
+		return scalar(t, r, codecUint[uint](strconv.IntSize)), true
+	case reflect.Uint8:
+		return scalar(t, r, codecUint[uint8](8)), true
+	case reflect.Uint16:
+		return scalar(t, r, codecUint[uint16](16)), true
+	case reflect.Uint32:
+		return scalar(t, r, codecUint[uint32](32)), true
+	case reflect.Uint64:
+		return scalar(t, r, codecUint[uint64](64)), true
+	case reflect.Float32:
+		return scalar(t, r, codec[float32]{
+			decode: func(s string) (Value, error) {
+				v, err := strconv.ParseFloat(s, 32)
+				return Value{Double: v}, err
+			},
+			encode: func(v float32) string { return strconv.FormatFloat(float64(v), 'f', -1, 32) },
+			value:  func(v Value) float32 { return float32(v.Double) },
+		}), true
+	default:
+		return nil, false
+	}
+}
+
+func codecInt[T int8 | int16 | int32 | int64](bitSize int) codec[T] {
+	return codec[T]{
+		decode: func(s string) (Value, error) {
+			v, err := strconv.ParseInt(s, 10, bitSize)
+			return Value{Number: int(v)}, err
+		},
+		encode: func(v T) string { return strconv.FormatInt(int64(v), 10) },
+		value:  func(v Value) T { return T(v.Number) },
+	}
+}
+
+func codecUint[T uint | uint8 | uint16 | uint32 | uint64](bitSize int) codec[T] {
+	return codec[T]{
+		decode: func(s string) (Value, error) {
+			v, err := strconv.ParseUint(s, 10, bitSize)
+			return Value{Number: int(v)}, err
+		},
+		encode: func(v T) string { return strconv.FormatUint(uint64(v), 10) },
+		value:  func(v Value) T { return T(v.Number) },
+	}
+}
+
+func scalar[S, A, T any](t hseq.Type[S], r optics.Reflector[A], c codec[T]) Lens {
+	if t.StructField.Type.Kind() == reflect.Pointer {
+		return &lensScalarPointer[A, T]{Reflector: r, codec: c}
+	}
+	return &lensScalar[A, T]{Reflector: r, codec: c}
+}
+
+// Lens to deal with scalar type T, A is either T or type defined over T
+type lensScalar[A, T any] struct {
+	optics.Reflector[A]
+	codec codec[T]
+}
+
+func (l *lensScalar[A, T]) Put(s any, a Value) error {
+	var t A
+	*(*T)(unsafe.Pointer(&t)) = l.codec.value(a)
+	l.Reflector.Putt(s, t)
+	return nil
+}
+
+func (l *lensScalar[A, T]) ToString(s any) (string, error) {
+	t := l.Reflector.Gett(s)
+	return l.codec.encode(*(*T)(unsafe.Pointer(&t))), nil
+}
+
+func (l *lensScalar[A, T]) FromString(a string) (Value, error) {
+	return l.codec.decode(a)
+}
+
+// Lens to deal with pointer to scalar type T
+type lensScalarPointer[A, T any] struct {
+	optics.Reflector[A]
+	codec codec[T]
+}
+
+func (l *lensScalarPointer[A, T]) Put(s any, a Value) error {
+	var t A
+	v := l.codec.value(a)
+	*(**T)(unsafe.Pointer(&t)) = &v
+	l.Reflector.Putt(s, t)
+	return nil
+}
+
+func (l *lensScalarPointer[A, T]) ToString(s any) (string, error) {
+	t := l.Reflector.Gett(s)
+	if v := *(**T)(unsafe.Pointer(&t)); v != nil {
+		return l.codec.encode(*v), nil
+	}
+	return "", nil
+}
+
+func (l *lensScalarPointer[A, T]) FromString(a string) (Value, error) {
+	return l.codec.decode(a)
+}
+
+// isTextUnmarshaler checks if type implements encoding.TextUnmarshaler
+func isTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(typeTextUnmarshaler)
+}
+
+// Lens to deal with types that implements encoding.TextUnmarshaler,
+// A is either the type or pointer to the type
+type lensText[A any] struct {
+	optics.Reflector[A]
+	pure    reflect.Type
+	pointer bool
+}
+
+func newLensText[S, A any](t hseq.Type[S], r optics.Reflector[A]) Lens {
+	return &lensText[A]{
+		Reflector: r,
+		pure:      t.PureType,
+		pointer:   t.StructField.Type.Kind() == reflect.Pointer,
+	}
+}
+
+func (l *lensText[A]) decode(a string) (A, error) {
+	var t A
+	if l.pointer {
+		v := reflect.New(l.pure)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(a)); err != nil {
+			return t, err
+		}
+		return v.Interface().(A), nil
+	}
+
+	err := any(&t).(encoding.TextUnmarshaler).UnmarshalText([]byte(a))
+	return t, err
+}
+
+func (l *lensText[A]) Put(s any, a Value) error {
+	t, err := l.decode(a.String)
+	if err != nil {
+		return err
+	}
+
+	l.Reflector.Putt(s, t)
+	return nil
+}
+
+func (l *lensText[A]) ToString(s any) (string, error) {
+	t := l.Reflector.Gett(s)
+
+	var v any = &t
+	if l.pointer {
+		if reflect.ValueOf(t).IsNil() {
+			return "", nil
+		}
+		v = t
+	}
+
+	if m, ok := v.(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+	return fmt.Sprint(reflect.ValueOf(v).Elem().Interface()), nil
+}
+
+// FromString keeps the text as-is, it is unmarshalled by Put. The text
+// is checked so that invalid default value is reported early.
+func (l *lensText[A]) FromString(a string) (Value, error) {
+	if _, err := l.decode(a); err != nil {
+		return Value{}, err
+	}
+	return Value{String: a}, nil
+}
+
+func (l *lensText[A]) Decode(a string) (Decoded, error) {
+	t, err := l.decode(a)
+	if err != nil {
+		return nil, err
+	}
+	return decoded[A]{Reflector: l.Reflector, value: t}, nil
+}
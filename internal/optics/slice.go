@@ -0,0 +1,237 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package optics
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogfish/golem/hseq"
+	"github.com/fogfish/golem/optics"
+)
+
+// Multi is implemented by lenses that focus on slices, each value of
+// repeated term (e.g. query param, multi-value header) is split on
+// separator, elements of all values are collected into the slice.
+type Multi interface {
+	FromStrings(seq []string) (Decoded, error)
+}
+
+// ElementError is the failure to decode element of slice
+type ElementError struct {
+	Index int
+	Err   error
+}
+
+func (err ElementError) Error() string {
+	return fmt.Sprintf("element %d: %s", err.Index, err.Err)
+}
+
+func (err ElementError) Unwrap() error { return err.Err }
+
+// Lens to deal with slices of scalar or text types. The value is
+// split on the separator defined by `sep` tag, comma by default.
+type lensSlice[A any] struct {
+	optics.Reflector[A]
+	sliceCodec
+}
+
+func newLensSlice[S, A any](t hseq.Type[S], r optics.Reflector[A]) Lens {
+	if t.StructField.Type.Kind() == reflect.Pointer {
+		panic(fmt.Errorf("type %v is not supported", t.Type))
+	}
+
+	return &lensSlice[A]{
+		Reflector:  r,
+		sliceCodec: newSliceCodec(t.StructField),
+	}
+}
+
+// sliceCodec splits the value to elements of slice and joins them back.
+// Every value of repeated term is split on separator, use `sep` tag to
+// keep values with comma (e.g. `sep:";"`).
+type sliceCodec struct {
+	slice  reflect.Type
+	sep    string
+	decode func(string) (reflect.Value, error)
+	encode func(reflect.Value) string
+}
+
+func newSliceCodec(f reflect.StructField) sliceCodec {
+	sep := f.Tag.Get("sep")
+	if sep == "" {
+		sep = ","
+	}
+
+	decode, encode := elementCodec(f.Type.Elem())
+	if decode == nil {
+		panic(fmt.Errorf("type %v is not supported", f.Type))
+	}
+
+	return sliceCodec{
+		slice:  f.Type,
+		sep:    sep,
+		decode: decode,
+		encode: encode,
+	}
+}
+
+func elementCodec(elem reflect.Type) (func(string) (reflect.Value, error), func(reflect.Value) string) {
+	encode := func(v reflect.Value) string { return fmt.Sprint(v.Interface()) }
+
+	switch {
+	case isTextUnmarshaler(elem):
+		return func(s string) (reflect.Value, error) {
+				v := reflect.New(elem)
+				err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+				return v.Elem(), err
+			}, func(v reflect.Value) string {
+				if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+					if b, err := m.MarshalText(); err == nil {
+						return string(b)
+					}
+				}
+				return fmt.Sprint(v.Interface())
+			}
+	case elem == typeDuration:
+		return func(s string) (reflect.Value, error) {
+			d, err := time.ParseDuration(s)
+			return reflect.ValueOf(d), err
+		}, encode
+	}
+
+	switch elem.Kind() {
+	case reflect.String:
+		return func(s string) (reflect.Value, error) {
+			return reflect.ValueOf(s).Convert(elem), nil
+		}, encode
+	case reflect.Bool:
+		return func(s string) (reflect.Value, error) {
+			b, err := strconv.ParseBool(s)
+			return reflect.ValueOf(b).Convert(elem), err
+		}, encode
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(s string) (reflect.Value, error) {
+			v := reflect.New(elem).Elem()
+			x, err := strconv.ParseInt(s, 10, elem.Bits())
+			v.SetInt(x)
+			return v, err
+		}, encode
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(s string) (reflect.Value, error) {
+			v := reflect.New(elem).Elem()
+			x, err := strconv.ParseUint(s, 10, elem.Bits())
+			v.SetUint(x)
+			return v, err
+		}, encode
+	case reflect.Float32, reflect.Float64:
+		return func(s string) (reflect.Value, error) {
+				v := reflect.New(elem).Elem()
+				x, err := strconv.ParseFloat(s, elem.Bits())
+				v.SetFloat(x)
+				return v, err
+			}, func(v reflect.Value) string {
+				return strconv.FormatFloat(v.Float(), 'f', -1, elem.Bits())
+			}
+	default:
+		return nil, nil
+	}
+}
+
+// elements of slice, every value is split on separator,
+// split elements are trimmed
+func (l sliceCodec) elements(seq []string) []string {
+	elements := make([]string, 0, len(seq))
+	for _, x := range seq {
+		if x == "" {
+			continue
+		}
+		for _, e := range strings.Split(x, l.sep) {
+			elements = append(elements, strings.TrimSpace(e))
+		}
+	}
+	return elements
+}
+
+func (l sliceCodec) decodeAll(seq []string) (reflect.Value, error) {
+	slice := reflect.MakeSlice(l.slice, len(seq), len(seq))
+	for i, x := range seq {
+		v, err := l.decode(x)
+		if err != nil {
+			return slice, ElementError{Index: i, Err: err}
+		}
+		slice.Index(i).Set(v)
+	}
+	return slice, nil
+}
+
+func (l sliceCodec) split(a string) (reflect.Value, error) {
+	return l.decodeAll(l.elements([]string{a}))
+}
+
+func (l *lensSlice[A]) FromStrings(seq []string) (Decoded, error) {
+	slice, err := l.decodeAll(l.elements(seq))
+	if err != nil {
+		return nil, err
+	}
+
+	return decoded[A]{Reflector: l.Reflector, value: slice.Interface().(A)}, nil
+}
+
+func (l *lensSlice[A]) Put(s any, a Value) error {
+	slice, err := l.split(a.String)
+	if err != nil {
+		return err
+	}
+
+	l.Reflector.Putt(s, slice.Interface().(A))
+	return nil
+}
+
+func (l *lensSlice[A]) ToString(s any) (string, error) {
+	return l.join(reflect.ValueOf(l.Reflector.Gett(s))), nil
+}
+
+func (l sliceCodec) join(slice reflect.Value) string {
+	seq := make([]string, slice.Len())
+	for i := range seq {
+		seq[i] = l.encode(slice.Index(i))
+	}
+	return strings.Join(seq, l.sep)
+}
+
+// FromString checks elements, the value is kept as-is and decoded by Put
+func (l *lensSlice[A]) FromString(a string) (Value, error) {
+	if _, err := l.split(a); err != nil {
+		return Value{}, err
+	}
+	return Value{String: a}, nil
+}
+
+func (l *lensSlice[A]) Decode(a string) (Decoded, error) {
+	slice, err := l.split(a)
+	if err != nil {
+		return nil, err
+	}
+	return decoded[A]{Reflector: l.Reflector, value: slice.Interface().(A)}, nil
+}
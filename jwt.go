@@ -19,6 +19,7 @@
 package gouldian
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/fogfish/gouldian/v2/internal/optics"
@@ -45,9 +46,9 @@ JWT combinator defines primitives to match JWT token in the HTTP requests.
 func JWT[T Pattern](claim JWTClaim, val T) Endpoint {
 	switch v := any(val).(type) {
 	case string:
-		return jwtClaim(claim).Is(v)
+		return declare(declaration{guard: true}, jwtClaim(claim).Is(v))
 	case Lens:
-		return jwtClaim(claim).To(v)
+		return declare(declaration{guard: true}, jwtClaim(claim).To(v))
 	default:
 		panic("type system failure")
 	}
@@ -61,11 +62,11 @@ Is matches a key of JWT to defined literal value
 func (claim jwtClaim) Is(val string) Endpoint {
 	return func(ctx *Context) error {
 		if ctx.JWT == nil {
-			return ErrNoMatch
+			return jwtNoMatch(http.StatusUnauthorized, "token "+reasonMissing)
 		}
 
 		if claim(ctx.JWT) != val {
-			return ErrNoMatch
+			return jwtNoMatch(http.StatusForbidden, "claim "+reasonMismatch)
 		}
 
 		return nil
@@ -76,18 +77,28 @@ func (claim jwtClaim) Is(val string) Endpoint {
 To matches key of JWT value to the request context. It uses lens abstraction to
 decode value into Golang type. The Endpoint causes no-match if param
 value cannot be decoded to the target type. See optics.Lens type for details.
+The missing claim does not cause no-match if lens has default value.
 */
 func (claim jwtClaim) To(lens optics.Lens) Endpoint {
+	def := defaultOf(lens)
+
 	return func(ctx *Context) error {
 		if ctx.JWT == nil {
-			return ErrNoMatch
+			return jwtNoMatch(http.StatusUnauthorized, "token "+reasonMissing)
 		}
 
-		if val := claim(ctx.JWT); val != "" {
-			return ctx.Put(lens, val)
+		val := claim(ctx.JWT)
+		if val == "" {
+			if ctx.putDefault(def) {
+				return nil
+			}
+			return jwtNoMatch(http.StatusForbidden, "claim "+reasonMissing)
 		}
 
-		return ErrNoMatch
+		if err := ctx.Put(lens, val); err != nil {
+			return jwtNoMatch(http.StatusBadRequest, "claim "+reasonInvalid)
+		}
+		return nil
 	}
 }
 
@@ -95,6 +106,7 @@ func (claim jwtClaim) To(lens optics.Lens) Endpoint {
 JWTMaybe matches key of JWT to the request context. It uses lens abstraction to
 decode value into Golang type. The Endpoint does not cause no-match
 if header value cannot be decoded to the target type. See optics.Lens type for details.
+The default value of lens, defined by `default` tag, is used if claim is missing.
 
 	type MyT struct{ Username string }
 
@@ -103,13 +115,17 @@ if header value cannot be decoded to the target type. See optics.Lens type for d
 	e(mock.Input(mock.JWT(µ.JWT{"username": "joedoe"}))) == nil
 */
 func JWTMaybe(claim JWTClaim, lens optics.Lens) Endpoint {
+	def := defaultOf(lens)
+
 	return func(ctx *Context) error {
 		if ctx.JWT == nil {
-			return ErrNoMatch
+			return jwtNoMatch(http.StatusUnauthorized, "token "+reasonMissing)
 		}
 
 		if val := claim(ctx.JWT); val != "" {
 			ctx.Put(lens, val)
+		} else {
+			ctx.putDefault(def)
 		}
 
 		return nil
@@ -124,7 +140,7 @@ JWTOneOf matches a key of JWT if it contains one of the tokens
 func JWTOneOf(claim JWTClaim, vals ...string) Endpoint {
 	return func(ctx *Context) error {
 		if ctx.JWT == nil {
-			return ErrNoMatch
+			return jwtNoMatch(http.StatusUnauthorized, "token "+reasonMissing)
 		}
 
 		val := claim(ctx.JWT)
@@ -134,7 +150,7 @@ func JWTOneOf(claim JWTClaim, vals ...string) Endpoint {
 			}
 		}
 
-		return ErrNoMatch
+		return jwtNoMatch(http.StatusForbidden, "claim "+reasonMismatch)
 	}
 }
 
@@ -146,16 +162,26 @@ JWTAllOf matches a key of JWT if it contains one of the tokens
 func JWTAllOf(claim JWTClaim, vals ...string) Endpoint {
 	return func(ctx *Context) error {
 		if ctx.JWT == nil {
-			return ErrNoMatch
+			return jwtNoMatch(http.StatusUnauthorized, "token "+reasonMissing)
 		}
 
 		val := claim(ctx.JWT)
 		for _, x := range vals {
 			if !strings.Contains(val, x) {
-				return ErrNoMatch
+				return jwtNoMatch(http.StatusForbidden, "claim "+reasonMismatch)
 			}
 		}
 
 		return nil
 	}
 }
+
+// jwtNoMatch reports the failure of JWT combinator, the token is
+// either missing (401) or does not grant access to the route (403).
+func jwtNoMatch(status int, reason string) error {
+	return NoMatch{
+		Status: status,
+		Source: "jwt",
+		Reason: reason,
+	}
+}
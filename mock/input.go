@@ -75,6 +75,22 @@ func URL(httpURL string) Mock {
 	}
 }
 
+// Host changes the host of mocked HTTP request
+func Host(host string) Mock {
+	return func(mock *µ.Context) *µ.Context {
+		mock.Request.Host = host
+		return mock
+	}
+}
+
+// RemoteAddr changes the network address of client of mocked HTTP request
+func RemoteAddr(addr string) Mock {
+	return func(mock *µ.Context) *µ.Context {
+		mock.Request.RemoteAddr = addr
+		return mock
+	}
+}
+
 // Header adds Header to mocked HTTP request
 func Header(header string, value string) Mock {
 	return func(mock *µ.Context) *µ.Context {
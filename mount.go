@@ -0,0 +1,145 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+/*
+Mount attaches the group of routes under the path prefix. The prefix is
+a Routable, its endpoints are shared by the group (e.g. auth or content-type
+guards). The route inherits HTTP verb of prefix if its own verb is not known.
+
+	µ.NewRoutes(
+		µ.Mount(
+			µ.Route(µ.URI(µ.Path("api"), µ.Path(tenant)), µ.Authorization(auth)),
+			µ.GET(µ.URI(µ.Path("users"))),
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(id))),
+		),
+	)
+
+Groups are nestable, use Node.Routable to mount the whole routing table.
+The group is not composable with endpoints, verb or name (see Route and Name),
+declare them at the prefix instead.
+*/
+func Mount(prefix Routable, seq ...Routable) Routable {
+	return func() Spec {
+		head := prefix()
+		var guard *Spec
+		if head.guard > 0 {
+			guard = &head
+		}
+
+		group := make([]Spec, 0, len(seq))
+		for _, route := range seq {
+			spec := route()
+			if spec.Group == nil {
+				group = append(group, mount(head, guard, spec))
+				continue
+			}
+
+			for _, heir := range spec.Group {
+				group = append(group, mount(head, guard, heir))
+			}
+		}
+
+		return Spec{Group: group}
+	}
+}
+
+// mount prefixes the route, path values captured by the router are split
+// between endpoints of prefix and route. Guards of the prefix are shared
+// by routes of the group, they are kept apart from guards of the route.
+func mount(head Spec, guard *Spec, spec Spec) Spec {
+	n := len(segmentsToLens(head.Segments))
+	prefix, endpoint := head.Func, spec.Func
+
+	segments := make([]Segment, 0, len(head.Segments)+len(spec.Segments))
+	segments = append(segments, head.Segments...)
+	segments = append(segments, spec.Segments...)
+
+	method := spec.Method
+	if method == "" {
+		method = head.Method
+	}
+
+	guards := spec.guards
+	if guard != nil {
+		guards = append([]*Spec{guard}, spec.guards...)
+	}
+
+	return Spec{
+		Name:     spec.Name,
+		Method:   method,
+		Segments: segments,
+		Meta:     mergeMeta(head.Meta, spec.Meta),
+		guard:    spec.guard,
+		handler:  spec.handler,
+		guards:   guards,
+		query:    append(append([]ReverseQuery{}, head.query...), spec.query...),
+		Func: func(ctx *Context) error {
+			values := ctx.values
+			if len(values) < n {
+				return ErrNoMatch
+			}
+
+			ctx.values = values[:n]
+			err := prefix(ctx)
+			if err == nil {
+				ctx.values = values[n:]
+				err = endpoint(ctx)
+			}
+			ctx.values = values
+
+			return err
+		},
+	}
+}
+
+/*
+Routable converts routing table to the group of routes, use it to mount
+the table into another one. Options of the table are not inherited.
+
+	µ.NewRoutes(
+		µ.Mount(µ.URI(µ.Path("v1")), v1.Routable()),
+	)
+*/
+func (root *Node) Routable() Routable {
+	return func() Spec {
+		group := make([]Spec, 0)
+		root.Walk(func(_ int, node *Node) {
+			group = append(group, node.Spec...)
+		})
+
+		return Spec{Group: group}
+	}
+}
+
+// mergeMeta of routes, values of b overrides a
+func mergeMeta(a, b map[string]string) map[string]string {
+	if len(a) == 0 {
+		return b
+	}
+
+	meta := make(map[string]string, len(a)+len(b))
+	for key, val := range a {
+		meta[key] = val
+	}
+	for key, val := range b {
+		meta[key] = val
+	}
+	return meta
+}
@@ -0,0 +1,131 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+func TestMount(t *testing.T) {
+	type myT struct{ Tenant, ID string }
+	tenant, id := µ.Optics2[myT, string, string]("Tenant", "ID")
+
+	foo := µ.NewRoutes(
+		µ.Mount(
+			µ.Route(µ.URI(µ.Path("api"), µ.Path(tenant)), µ.Header("X-Foo", "bar")),
+			µ.GET(µ.URI(µ.Path("users")), mock.Output(http.StatusOK, "users")),
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(id))),
+		),
+		µ.GET(µ.URI(µ.Path("users")), mock.Output(http.StatusOK, "root")),
+	).Endpoint()
+
+	t.Run("Match", func(t *testing.T) {
+		var val myT
+		req := mock.Input(mock.URL("/api/t1/users/u1"), mock.Header("X-Foo", "bar"))
+		it.Then(t).Should(
+			it.Nil(foo(req)),
+			it.Nil(µ.FromContext(req, &val)),
+			it.Equal(val.Tenant, "t1"),
+			it.Equal(val.ID, "u1"),
+		)
+	})
+
+	t.Run("Guard", func(t *testing.T) {
+		req := mock.Input(mock.URL("/api/t1/users"))
+		it.Then(t).Should(
+			it.Equal(foo(req), errNoFoo),
+		)
+	})
+
+	t.Run("Sibling", func(t *testing.T) {
+		req := mock.Input(mock.URL("/users"))
+		it.Then(t).Should(
+			it.Nil(mock.CheckOutput(foo(req), "root")),
+		)
+	})
+}
+
+func TestMountNested(t *testing.T) {
+	type myT struct{ Version, ID string }
+	version, id := µ.Optics2[myT, string, string]("Version", "ID")
+
+	users := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("users"), µ.Path(id))),
+		µ.POST(µ.URI(µ.Path("users"))),
+	)
+
+	routes := µ.NewRoutes(
+		µ.Annotate(
+			µ.Mount(
+				µ.Route(µ.URI(µ.Path("api"))),
+				µ.Mount(µ.Route(µ.URI(µ.Path(version))), users.Routable()),
+			),
+			map[string]string{"group": "users"},
+		),
+	)
+
+	var val myT
+	req := mock.Input(mock.URL("/api/v1/users/u1"))
+	it.Then(t).Should(
+		it.Nil(routes.Endpoint()(req)),
+		it.Nil(µ.FromContext(req, &val)),
+		it.Equal(val.Version, "v1"),
+		it.Equal(val.ID, "u1"),
+	)
+
+	info := routes.Routes()
+	it.Then(t).Should(
+		it.Equal(len(info), 2),
+		it.Equal(info[0].Method, "POST"),
+		it.Equal(info[0].Path, "/api/:Version/users"),
+		it.Equal(info[0].Meta["group"], "users"),
+		it.Equal(info[1].Method, "GET"),
+		it.Equal(info[1].Path, "/api/:Version/users/:ID"),
+	)
+}
+
+func TestMountWrapped(t *testing.T) {
+	group := µ.Mount(
+		µ.Route(µ.URI(µ.Path("api"))),
+		µ.GET(µ.URI(µ.Path("users"))),
+	)
+	auth := func(*µ.Context) error { return nil }
+
+	for name, route := range map[string]µ.Routable{
+		"Guard": µ.Route(group, auth),
+		"Verb":  µ.GET(group),
+		"Name":  µ.Name("users", group),
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				it.Then(t).ShouldNot(
+					it.Nil(recover()),
+				)
+			}()
+
+			µ.NewRoutes(route)
+			t.Error("group is registered")
+		})
+	}
+}
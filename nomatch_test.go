@@ -0,0 +1,172 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+func TestEndpointOrMostSpecific(t *testing.T) {
+	var bad µ.Endpoint = func(x *µ.Context) error { return µ.NoMatch{Status: 400, Source: "param"} }
+	var auth µ.Endpoint = func(x *µ.Context) error { return µ.NoMatch{Status: 401, Source: "jwt"} }
+	var none µ.Endpoint = func(x *µ.Context) error { return µ.ErrNoMatch }
+
+	for _, c := range []µ.Endpoint{
+		none.Or(auth).Or(bad),
+		bad.Or(auth).Or(none),
+		µ.Or(none, bad, auth),
+		µ.Or(auth, none, bad),
+	} {
+		it.Then(t).Should(
+			it.Equal(c(mock.Input()), error(µ.NoMatch{Status: 400, Source: "param"})),
+		)
+	}
+}
+
+func TestNoMatchDiagnostic(t *testing.T) {
+	type T struct{ Limit int }
+	limit := µ.Optics1[T, int]()
+
+	for _, tt := range []struct {
+		endpoint µ.Endpoint
+		input    *µ.Context
+		expect   error
+	}{
+		{
+			µ.Param("limit", limit),
+			mock.Input(mock.URL("/?limit=abc")),
+			µ.NoMatch{Status: 400, Source: "param", Key: "limit", Reason: "is not valid"},
+		},
+		{
+			µ.Param("limit", limit),
+			mock.Input(mock.URL("/")),
+			µ.NoMatch{Status: 400, Source: "param", Key: "limit", Reason: "is missing"},
+		},
+		{
+			µ.Header("X-Limit", limit),
+			mock.Input(mock.Header("X-Limit", "abc")),
+			µ.NoMatch{Status: 400, Source: "header", Key: "X-Limit", Reason: "is not valid"},
+		},
+		{
+			µ.ContentType.JSON,
+			mock.Input(mock.Header("Content-Type", "text/plain")),
+			µ.NoMatch{Status: 415, Source: "header", Key: "Content-Type", Reason: "is not matched"},
+		},
+		{
+			µ.JWT(µ.Token.Sub, "joe"),
+			mock.Input(),
+			µ.NoMatch{Status: 401, Source: "jwt", Reason: "token is missing"},
+		},
+		{
+			µ.JWT(µ.Token.Sub, "joe"),
+			mock.Input(mock.JWT(µ.Token{"sub": "doe"})),
+			µ.NoMatch{Status: 403, Source: "jwt", Reason: "claim is not matched"},
+		},
+		{
+			µ.Body(limit),
+			mock.Input(),
+			µ.NoMatch{Status: 400, Source: "body", Reason: "is missing"},
+		},
+	} {
+		it.Then(t).Should(
+			it.Equal(tt.endpoint(tt.input), tt.expect),
+		)
+	}
+}
+
+func TestNoMatchInvalidOverMissing(t *testing.T) {
+	type T struct {
+		Q string
+		V int
+	}
+	q, v := µ.Optics2[T, string, int]()
+
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("echo")), µ.Param("q", q)),
+		µ.GET(µ.URI(µ.Path("echo")), µ.Param("v", v)),
+	).Endpoint()
+
+	it.Then(t).Should(
+		it.Equal(
+			foo(mock.Input(mock.URL("/echo?v=abc"))),
+			error(µ.NoMatch{Status: 400, Source: "param", Key: "v", Reason: "is not valid"}),
+		),
+		it.Equal(
+			foo(mock.Input(mock.URL("/echo?v=1&q"))),
+			nil,
+		),
+	)
+}
+
+func TestNoMatchPath(t *testing.T) {
+	type T struct{ ID int }
+	id := µ.Optics1[T, int]()
+
+	foo := mock.Endpoint(µ.GET(µ.URI(µ.Path("items"), µ.Path(id))))
+
+	it.Then(t).Should(
+		it.Equal(
+			foo(mock.Input(mock.URL("/items/abc"))),
+			error(µ.NoMatch{Status: 400, Source: "path", Key: "ID", Reason: "is not valid"}),
+		),
+	)
+}
+
+func TestNoMatchPathTagName(t *testing.T) {
+	type T struct {
+		ID   int `json:"id"`
+		Page int `path:"page" json:"p"`
+	}
+	id, page := µ.Optics2[T, int, int]("ID", "Page")
+
+	foo := mock.Endpoint(µ.GET(µ.URI(µ.Path("items"), µ.Path(id), µ.Path(page))))
+
+	it.Then(t).Should(
+		it.Equal(
+			foo(mock.Input(mock.URL("/items/abc/1"))),
+			error(µ.NoMatch{Status: 400, Source: "path", Key: "id", Reason: "is not valid"}),
+		),
+		it.Equal(
+			foo(mock.Input(mock.URL("/items/1/abc"))),
+			error(µ.NoMatch{Status: 400, Source: "path", Key: "page", Reason: "is not valid"}),
+		),
+	)
+}
+
+func TestNoMatchOutput(t *testing.T) {
+	err := µ.NoMatch{Status: 400, Source: "param", Key: "limit", Reason: "is not valid"}
+	out := err.Output()
+
+	it.Then(t).Should(
+		it.Equal(err.Error(), "No Match: param limit is not valid"),
+		it.Equal(out.Status, 400),
+		it.Equal(out.GetHeader("Content-Type"), "application/json"),
+		it.String(out.Body).Contain(`"detail":"param limit is not valid"`),
+		it.String(out.Body).Contain(`"invalid-params":[{"name":"limit","reason":"is not valid"}]`),
+	)
+
+	it.Then(t).Should(
+		it.Equal(µ.ErrNoMatch.(µ.NoMatch).Output().Status, 501),
+		it.Equal(µ.ErrNotFound.(µ.NoMatch).Output().Status, 404),
+	)
+}
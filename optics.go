@@ -1,18 +1,94 @@
 package gouldian
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+
 	"github.com/fogfish/golem/hseq"
 	lenses "github.com/fogfish/golem/optics"
 	"github.com/fogfish/gouldian/v2/internal/optics"
 )
 
 // Lens type
-type Lens struct{ optics.Lens }
+type Lens struct {
+	optics.Lens
+	target reflect.Type        // type of struct focused by lens
+	field  reflect.StructField // field of struct focused by lens
+}
 
 func newLens[S, A any](ln func(t hseq.Type[S]) lenses.Lens[S, A]) func(hseq.Type[S]) Lens {
 	return func(t hseq.Type[S]) Lens {
-		return Lens{optics.NewLens(ln)(t)}
+		return Lens{
+			Lens:   optics.NewLens(ln)(t),
+			target: reflect.TypeOf(new(S)).Elem(),
+			field:  t.StructField,
+		}
+	}
+}
+
+// seqOf unfolds attributes of type T. The attribute is either name of field
+// or dotted path to the field of nested struct, e.g. Key.Tenant.
+func seqOf[T any](attr ...string) hseq.Seq[T] {
+	seq := hseq.New[T]()
+	nseq := make(hseq.Seq[T], len(attr))
+	for i, path := range attr {
+		nseq[i] = forPath(seq, path)
+	}
+
+	return nseq
+}
+
+// forPath looks up the field of nested struct, the name of nested field
+// is the path to the field
+func forPath[T any](seq hseq.Seq[T], path string) hseq.Type[T] {
+	name, tail, nested := strings.Cut(path, ".")
+	t, ok := hseq.ForNameMaybe(seq, name)
+	if !ok {
+		// embedded struct is unfolded by hseq
+		t = fieldOf[T](reflect.TypeOf(new(T)).Elem(), name, 0)
+	}
+
+	for nested {
+		if t.StructField.Type.Kind() != reflect.Struct {
+			panic(fmt.Errorf("%s is not nested struct of %s type", name, reflect.TypeOf(new(T)).Elem().Name()))
+		}
+
+		offset := t.RootOffs + t.Offset
+		name, tail, nested = strings.Cut(tail, ".")
+		t = fieldOf[T](t.StructField.Type, name, offset)
+	}
+
+	if name != path {
+		t.StructField.Name = path
+	}
+	return t
+}
+
+// fieldOf looks up the field of struct cat, the offset is position of
+// the struct within type T
+func fieldOf[T any](cat reflect.Type, name string, offset uintptr) hseq.Type[T] {
+	f, ok := cat.FieldByName(name)
+	if !ok {
+		panic(fmt.Errorf("%s is not member of %s type", name, cat.Name()))
 	}
+
+	// field is promoted from embedded struct
+	for _, i := range f.Index[:len(f.Index)-1] {
+		embedded := cat.Field(i)
+		if embedded.Type.Kind() != reflect.Struct {
+			panic(fmt.Errorf("%s is not member of %s type", name, cat.Name()))
+		}
+		offset += embedded.Offset
+		cat = embedded.Type
+	}
+
+	pure := f.Type
+	if pure.Kind() == reflect.Pointer {
+		pure = pure.Elem()
+	}
+
+	return hseq.Type[T]{StructField: f, RootOffs: offset, PureType: pure}
 }
 
 // Optics1 unfold attribute(s) of type T
@@ -22,7 +98,7 @@ func Optics1[T, A any](attr ...string) Lens {
 	if len(attr) == 0 {
 		seq = hseq.New1[T, A]()
 	} else {
-		seq = hseq.New[T](attr[0])
+		seq = seqOf[T](attr[0])
 	}
 
 	return hseq.FMap1(seq,
@@ -37,7 +113,7 @@ func Optics2[T, A, B any](attr ...string) (Lens, Lens) {
 	if len(attr) == 0 {
 		seq = hseq.New2[T, A, B]()
 	} else {
-		seq = hseq.New[T](attr[0:2]...)
+		seq = seqOf[T](attr[0:2]...)
 	}
 
 	return hseq.FMap2(seq,
@@ -53,7 +129,7 @@ func Optics3[T, A, B, C any](attr ...string) (Lens, Lens, Lens) {
 	if len(attr) == 0 {
 		seq = hseq.New3[T, A, B, C]()
 	} else {
-		seq = hseq.New[T](attr[0:3]...)
+		seq = seqOf[T](attr[0:3]...)
 	}
 
 	return hseq.FMap3(seq,
@@ -70,7 +146,7 @@ func Optics4[T, A, B, C, D any](attr ...string) (Lens, Lens, Lens, Lens) {
 	if len(attr) == 0 {
 		seq = hseq.New4[T, A, B, C, D]()
 	} else {
-		seq = hseq.New[T](attr[0:4]...)
+		seq = seqOf[T](attr[0:4]...)
 	}
 
 	return hseq.FMap4(seq,
@@ -88,7 +164,7 @@ func Optics5[T, A, B, C, D, E any](attr ...string) (Lens, Lens, Lens, Lens, Lens
 	if len(attr) == 0 {
 		seq = hseq.New5[T, A, B, C, D, E]()
 	} else {
-		seq = hseq.New[T](attr[0:5]...)
+		seq = seqOf[T](attr[0:5]...)
 	}
 
 	return hseq.FMap5(seq,
@@ -107,7 +183,7 @@ func Optics6[T, A, B, C, D, E, F any](attr ...string) (Lens, Lens, Lens, Lens, L
 	if len(attr) == 0 {
 		seq = hseq.New6[T, A, B, C, D, E, F]()
 	} else {
-		seq = hseq.New[T](attr[0:6]...)
+		seq = seqOf[T](attr[0:6]...)
 	}
 
 	return hseq.FMap6(seq,
@@ -127,7 +203,7 @@ func Optics7[T, A, B, C, D, E, F, G any](attr ...string) (Lens, Lens, Lens, Lens
 	if len(attr) == 0 {
 		seq = hseq.New7[T, A, B, C, D, E, F, G]()
 	} else {
-		seq = hseq.New[T](attr[0:7]...)
+		seq = seqOf[T](attr[0:7]...)
 	}
 
 	return hseq.FMap7(seq,
@@ -148,7 +224,7 @@ func Optics8[T, A, B, C, D, E, F, G, H any](attr ...string) (Lens, Lens, Lens, L
 	if len(attr) == 0 {
 		seq = hseq.New8[T, A, B, C, D, E, F, G, H]()
 	} else {
-		seq = hseq.New[T](attr[0:8]...)
+		seq = seqOf[T](attr[0:8]...)
 	}
 
 	return hseq.FMap8(seq,
@@ -170,7 +246,7 @@ func Optics9[T, A, B, C, D, E, F, G, H, I any](attr ...string) (Lens, Lens, Lens
 	if len(attr) == 0 {
 		seq = hseq.New9[T, A, B, C, D, E, F, G, H, I]()
 	} else {
-		seq = hseq.New[T](attr[0:9]...)
+		seq = seqOf[T](attr[0:9]...)
 	}
 
 	return hseq.FMap9(seq,
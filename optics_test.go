@@ -1,7 +1,10 @@
 package gouldian_test
 
 import (
+	"encoding/hex"
+	"fmt"
 	"testing"
+	"time"
 
 	µ "github.com/fogfish/gouldian/v2"
 	"github.com/fogfish/gouldian/v2/mock"
@@ -169,3 +172,230 @@ func TestLenses9(t *testing.T) {
 		If(v.H).Equal("h").
 		If(v.I).Equal("i")
 }
+
+type lensID [2]byte
+
+func (id *lensID) UnmarshalText(b []byte) error {
+	if len(b) != 4 {
+		return fmt.Errorf("invalid id %s", b)
+	}
+	_, err := hex.Decode(id[:], b)
+	return err
+}
+
+func (id lensID) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(id[:])), nil
+}
+
+func TestLensesTypes(t *testing.T) {
+	type T struct {
+		ID      lensID
+		Enabled bool
+		Size    uint32
+		Timeout *time.Duration
+		Since   time.Time
+		Org     int64
+	}
+	id, enabled, size, timeout, since, org := µ.Optics6[T, lensID, bool, uint32, *time.Duration, time.Time, int64]()
+
+	foo := mock.Endpoint(
+		µ.GET(
+			µ.URI(µ.Path("t"), µ.Path(id)),
+			µ.Param("enabled", enabled),
+			µ.Param("size", size),
+			µ.Header("X-Timeout", timeout),
+			µ.Header("X-Since", since),
+			µ.JWT(µ.Token.ClientID, org),
+		),
+	)
+
+	t.Run("Match", func(t *testing.T) {
+		req := mock.Input(
+			mock.URL("/t/cafe?enabled=true&size=4000000000"),
+			mock.Header("X-Timeout", "1m"),
+			mock.Header("X-Since", "2024-01-02T03:04:05Z"),
+			mock.JWT(µ.Token{"client_id": "9000000000"}),
+		)
+
+		var v T
+		it.Ok(t).
+			If(foo(req)).Should().Equal(nil).
+			If(µ.FromContext(req, &v)).Should().Equal(nil).
+			If(v.ID).Equal(lensID{0xca, 0xfe}).
+			If(v.Enabled).Equal(true).
+			If(v.Size).Equal(uint32(4000000000)).
+			If(*v.Timeout).Equal(time.Minute).
+			If(v.Since).Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)).
+			If(v.Org).Equal(int64(9000000000))
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		req := mock.Input(
+			mock.URL("/t/xyz?enabled=true&size=1"),
+			mock.Header("X-Timeout", "1m"),
+			mock.Header("X-Since", "2024-01-02T03:04:05Z"),
+			mock.JWT(µ.Token{"client_id": "1"}),
+		)
+
+		it.Ok(t).
+			If(foo(req)).ShouldNot().Equal(nil)
+	})
+}
+
+func TestLensesSlice(t *testing.T) {
+	type T struct {
+		Tags  []string
+		IDs   []int
+		Langs []string `sep:";"`
+	}
+	tags, ids, langs := µ.Optics3[T, []string, []int, []string]("Tags", "IDs", "Langs")
+
+	foo := mock.Endpoint(
+		µ.GET(
+			µ.URI(µ.Path("t")),
+			µ.Param("tag", tags),
+			µ.ParamMaybe("id", ids),
+			µ.Header("X-Lang", langs),
+		),
+	)
+
+	t.Run("Match", func(t *testing.T) {
+		req := mock.Input(
+			mock.URL("/t?tag=a&tag=b,c&id=1&id=2"),
+			mock.Header("X-Lang", "en; fi"),
+		)
+
+		var v T
+		it.Ok(t).
+			If(foo(req)).Should().Equal(nil).
+			If(µ.FromContext(req, &v)).Should().Equal(nil).
+			If(v.Tags).Equal([]string{"a", "b", "c"}).
+			If(v.IDs).Equal([]int{1, 2}).
+			If(v.Langs).Equal([]string{"en", "fi"})
+	})
+
+	t.Run("SingleValue", func(t *testing.T) {
+		req := mock.Input(mock.URL("/t?tag=a,%20b&id=1"), mock.Header("X-Lang", "en"))
+
+		var v T
+		it.Ok(t).
+			If(foo(req)).Should().Equal(nil).
+			If(µ.FromContext(req, &v)).Should().Equal(nil).
+			If(v.Tags).Equal([]string{"a", "b"}).
+			If(v.IDs).Equal([]int{1})
+	})
+
+	t.Run("RepeatedValue", func(t *testing.T) {
+		req := mock.Input(mock.URL("/t?tag=Smith,%20John&tag=Doe"), mock.Header("X-Lang", "en"))
+
+		var v T
+		it.Ok(t).
+			If(foo(req)).Should().Equal(nil).
+			If(µ.FromContext(req, &v)).Should().Equal(nil).
+			If(v.Tags).Equal([]string{"Smith", "John", "Doe"})
+	})
+
+	t.Run("RepeatedValueSeparator", func(t *testing.T) {
+		bar := mock.Endpoint(µ.GET(µ.URI(µ.Path("t")), µ.Param("lang", langs)))
+		req := mock.Input(mock.URL("/t?lang=Smith,%20John%3BDoe&lang=Roe"))
+
+		var v T
+		it.Ok(t).
+			If(bar(req)).Should().Equal(nil).
+			If(µ.FromContext(req, &v)).Should().Equal(nil).
+			If(v.Langs).Equal([]string{"Smith, John", "Doe", "Roe"})
+	})
+
+	t.Run("MultiValueHeader", func(t *testing.T) {
+		req := mock.Input(mock.URL("/t?tag=a"))
+		req.Request.Header.Add("X-Lang", "en")
+		req.Request.Header.Add("X-Lang", "fi;sv")
+
+		var v T
+		it.Ok(t).
+			If(foo(req)).Should().Equal(nil).
+			If(µ.FromContext(req, &v)).Should().Equal(nil).
+			If(v.Langs).Equal([]string{"en", "fi", "sv"})
+	})
+
+	t.Run("RepeatedHeader", func(t *testing.T) {
+		bar := mock.Endpoint(µ.GET(µ.URI(µ.Path("t")), µ.Header("X-Tag", tags)))
+		req := mock.Input(mock.URL("/t"))
+		req.Request.Header.Add("X-Tag", "a")
+		req.Request.Header.Add("X-Tag", "b, c")
+
+		var v T
+		it.Ok(t).
+			If(bar(req)).Should().Equal(nil).
+			If(µ.FromContext(req, &v)).Should().Equal(nil).
+			If(v.Tags).Equal([]string{"a", "b", "c"})
+	})
+
+	t.Run("ElementError", func(t *testing.T) {
+		bar := mock.Endpoint(µ.GET(µ.URI(µ.Path("t")), µ.Param("id", ids)))
+		req := mock.Input(mock.URL("/t?id=1&id=x"))
+
+		it.Ok(t).
+			If(bar(req)).Should().Equal(
+			µ.NoMatch{Status: 400, Source: "param", Key: "id", Reason: "element 1 is not valid"},
+		)
+	})
+}
+
+func TestLensesNested(t *testing.T) {
+	type Key struct {
+		Tenant string
+		ID     int
+	}
+	type Page struct{ Limit int }
+	type T struct {
+		Key
+		Page Page
+		Sort string
+	}
+	tenant, id, limit := µ.Optics3[T, string, int, int]("Key.Tenant", "Key.ID", "Page.Limit")
+
+	foo := mock.Endpoint(
+		µ.GET(
+			µ.URI(µ.Path("t"), µ.Path(tenant), µ.Path(id)),
+			µ.ParamMaybe("limit", limit),
+			µ.FMap(func(ctx *µ.Context, v *T) error {
+				if !ctx.IsBound("Key.Tenant") || ctx.IsBound("Page.Limit") {
+					return µ.ErrNoMatch
+				}
+				return nil
+			}),
+		),
+	)
+
+	req := mock.Input(mock.URL("/t/acme/10"))
+
+	var v T
+	it.Ok(t).
+		If(foo(req)).Should().Equal(nil).
+		If(µ.FromContext(req, &v)).Should().Equal(nil).
+		If(v.Tenant).Equal("acme").
+		If(v.ID).Equal(10).
+		If(v.Page.Limit).Equal(0)
+
+	it.Ok(t).If(func() { µ.Optics1[T, string]("Key.Unknown") }).Should().Fail()
+	it.Ok(t).If(func() { µ.Optics1[T, string]("Sort.Value") }).Should().Fail()
+}
+
+func TestLensesMap(t *testing.T) {
+	type T struct {
+		Params map[string]string
+		Tags   map[string][]string
+	}
+	params, tags := µ.Optics2[T, map[string]string, map[string][]string]("Params", "Tags")
+
+	foo := mock.Endpoint(µ.GET(µ.URI(µ.Path("t")), µ.Params(params), µ.Params(tags)))
+	req := mock.Input(mock.URL("/t?a=1&b=2&b=3"))
+
+	var v T
+	it.Ok(t).
+		If(foo(req)).Should().Equal(nil).
+		If(µ.FromContext(req, &v)).Should().Equal(nil).
+		If(v.Params).Equal(map[string]string{"a": "1", "b": "2"}).
+		If(v.Tags).Equal(map[string][]string{"a": {"1"}, "b": {"2", "3"}})
+}
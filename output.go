@@ -99,6 +99,10 @@ func (out *Output) SetIssue(failure error, title ...string) {
 		issue.Title = title[0]
 	}
 
+	out.setIssue(issue, failure)
+}
+
+func (out *Output) setIssue(issue Issue, failure error) {
 	body, err := json.Marshal(issue)
 	if err != nil {
 		out.Status = http.StatusInternalServerError
@@ -141,6 +145,9 @@ type Issue struct {
 	Type   string `json:"type"`
 	Status int    `json:"status"`
 	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
 }
 
 // NewIssue creates instance of Issue
@@ -19,6 +19,7 @@
 package gouldian
 
 import (
+	"net/http"
 	"net/url"
 
 	"github.com/fogfish/gouldian/v2/internal/optics"
@@ -37,7 +38,10 @@ func Params(lens Lens) Endpoint {
 			ctx.params = Query(ctx.Request.URL.Query())
 		}
 
-		return ctx.Put(lens, ctx.Request.URL.RawQuery)
+		if err := ctx.Put(lens, ctx.Request.URL.RawQuery); err != nil {
+			return paramNoMatch("", reasonInvalid)
+		}
+		return nil
 	}
 }
 
@@ -57,9 +61,16 @@ Param combinator defines primitives to match query param in the HTTP requests.
 func Param[T Pattern](key string, val T) Endpoint {
 	switch v := any(val).(type) {
 	case string:
-		return param(key).Is(v)
+		return declare(
+			declaration{guard: true, query: []ReverseQuery{{key: key, value: v}}},
+			param(key).Is(v),
+		)
 	case Lens:
-		return param(key).To(v)
+		_, maybe := v.field.Tag.Lookup("default")
+		return declare(
+			declaration{guard: true, query: []ReverseQuery{{key: key, lens: &v, maybe: maybe}}},
+			param(key).To(v),
+		)
 	default:
 		panic("type system failure")
 	}
@@ -75,13 +86,17 @@ ParamAny is a wildcard matcher of param key. It fails if key is not defined.
 	e(mock.Input()) != nil
 */
 func ParamAny(key string) Endpoint {
-	return param(key).Any
+	return declare(
+		declaration{guard: true, query: []ReverseQuery{{key: key, value: Any}}},
+		param(key).Any,
+	)
 }
 
 /*
 ParamMaybe matches param value to the request context. It uses lens abstraction to
 decode value into Golang type. The Endpoint does not cause no-match
 if header value cannot be decoded to the target type. See optics.Lens type for details.
+The default value of lens, defined by `default` tag, is used if param is missing.
 
 	  type myT struct{ Val string }
 
@@ -92,16 +107,25 @@ if header value cannot be decoded to the target type. See optics.Lens type for d
 		e(mock.Input(mock.URL("/"))) == nil
 */
 func ParamMaybe(key string, lens Lens) Endpoint {
-	return func(ctx *Context) error {
-		if ctx.params == nil {
-			ctx.params = Query(ctx.Request.URL.Query())
-		}
-
-		if opt, exists := ctx.params.Get(string(key)); exists {
-			ctx.Put(lens, opt)
-		}
-		return nil
-	}
+	multi := multiOf(lens)
+	decoder := decoderOf(lens)
+	def := defaultOf(lens)
+
+	return declare(
+		declaration{query: []ReverseQuery{{key: key, lens: &lens, maybe: true}}},
+		func(ctx *Context) error {
+			if ctx.params == nil {
+				ctx.params = Query(ctx.Request.URL.Query())
+			}
+
+			if seq, exists := ctx.params[key]; exists {
+				ctx.putValues(lens, decoder, multi, seq)
+			} else {
+				ctx.putDefault(def)
+			}
+			return nil
+		},
+	)
 }
 
 /*
@@ -116,15 +140,18 @@ func ParamJSON(key string, lens Lens) Endpoint {
 
 		opt, exists := ctx.params.Get(string(key))
 		if !exists {
-			return ErrNoMatch
+			return paramNoMatch(key, reasonMissing)
 		}
 
 		str, err := url.QueryUnescape(opt)
 		if err != nil {
-			return ErrNoMatch
+			return paramNoMatch(key, reasonInvalid)
 		}
 
-		return ctx.Put(lens, str)
+		if err := ctx.Put(lens, str); err != nil {
+			return paramNoMatch(key, reasonInvalid)
+		}
+		return nil
 	}
 }
 
@@ -171,10 +198,14 @@ func (key param) Is(val string) Endpoint {
 		}
 
 		opt, exists := ctx.params.Get(string(key))
-		if exists && opt == val {
+		switch {
+		case !exists:
+			return paramNoMatch(string(key), reasonMissing)
+		case opt != val:
+			return paramNoMatch(string(key), reasonMismatch)
+		default:
 			return nil
 		}
-		return ErrNoMatch
 	}
 }
 
@@ -190,24 +221,45 @@ func (key param) Any(ctx *Context) error {
 	if exists {
 		return nil
 	}
-	return ErrNoMatch
+	return paramNoMatch(string(key), reasonMissing)
 }
 
 /*
 To matches param value to the request context. It uses lens abstraction to
 decode value into Golang type. The Endpoint causes no-match if param
 value cannot be decoded to the target type. See optics.Lens type for details.
+The missing param does not cause no-match if lens has default value.
 */
 func (key param) To(lens optics.Lens) Endpoint {
+	multi := multiOf(lens)
+	decoder := decoderOf(lens)
+	def := defaultOf(lens)
+
 	return func(ctx *Context) error {
 		if ctx.params == nil {
 			ctx.params = Query(ctx.Request.URL.Query())
 		}
 
-		opt, exists := ctx.params.Get(string(key))
-		if exists {
-			return ctx.Put(lens, opt)
+		seq, exists := ctx.params[string(key)]
+		if !exists {
+			if ctx.putDefault(def) {
+				return nil
+			}
+			return paramNoMatch(string(key), reasonMissing)
 		}
-		return ErrNoMatch
+
+		if err := ctx.putValues(lens, decoder, multi, seq); err != nil {
+			return paramNoMatch(string(key), invalid(err))
+		}
+		return nil
+	}
+}
+
+func paramNoMatch(key, reason string) error {
+	return NoMatch{
+		Status: http.StatusBadRequest,
+		Source: "param",
+		Key:    key,
+		Reason: reason,
 	}
 }
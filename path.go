@@ -19,13 +19,21 @@
 package gouldian
 
 import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
 	"github.com/fogfish/gouldian/v2/internal/optics"
 )
 
 // Segment union type, make URI type safe
 type Segment struct {
-	optics *Lens
-	path   string
+	optics  *Lens       // lens that lifts segment value to context
+	path    string      // literal value or wildcard symbol
+	is      *Constraint // constraint of wildcard segment, nil if any value matches
+	pattern *pattern    // partial-segment pattern, see PathPattern
 }
 
 /*
@@ -40,9 +48,8 @@ match to pattern
 	e(mock.Input(mock.URL("/bar"))) != nil
 */
 func URI(segments ...Segment) Routable {
-	return func() ([]string, Endpoint) {
-		path, lens := segmentsToLens(segments, true)
-		return path, segmentsToEndpoint(path, lens)
+	return func() Spec {
+		return Spec{Segments: segments, Func: segmentsToEndpoint(segmentsToLens(segments))}
 	}
 }
 
@@ -54,13 +61,24 @@ or lens. The function do not match if segment do not match to pattern
 	e := µ.GET( µ.URI(µ.Path("foo")) )
 	e(mock.Input(mock.URL("/foo"))) == nil
 	e(mock.Input(mock.URL("/bar"))) != nil
+
+Lens segments optionally accept constraints. The router matches the segment
+only if its value satisfies all constraints, other routes are tried otherwise.
+
+	µ.URI(µ.Path("items"), µ.Path(id, µ.IsInt()))
 */
-func Path[T Pattern](segment T) Segment {
+func Path[T Pattern](segment T, is ...Constraint) Segment {
 	switch v := any(segment).(type) {
 	case string:
+		if v == Any {
+			return PathAny(is...)
+		}
+		if len(is) != 0 {
+			panic("constraint is not applicable to literal segment " + v)
+		}
 		return Segment{path: v}
 	case Lens:
-		return Segment{optics: &v, path: ":"}
+		return Segment{optics: &v, path: ":", is: allOf(is)}
 	default:
 		panic("")
 	}
@@ -69,8 +87,8 @@ func Path[T Pattern](segment T) Segment {
 /*
 PathAny is a synonym of µ.Path("_"), it matches any segments
 */
-func PathAny() Segment {
-	return Segment{path: "_"}
+func PathAny(is ...Constraint) Segment {
+	return Segment{path: Any, is: allOf(is)}
 }
 
 /*
@@ -80,34 +98,294 @@ func PathAll(segment Lens) Segment {
 	return Segment{optics: &segment, path: "*"}
 }
 
-func segmentsToLens(segments []Segment, strict bool) ([]string, []optics.Lens) {
+/*
+PathPattern matches a single URL segment to the pattern composed of literals
+and placeholders `:`. Each placeholder lifts a part of segment value to the
+context using corresponding lens. Placeholders are greedy, the last occurrence
+of literal terminates the placeholder.
+
+	// matches /files/report.v2.pdf, name is "report.v2", ext is "pdf"
+	µ.URI(µ.Path("files"), µ.PathPattern(":.:", name, ext))
+*/
+func PathPattern(expr string, lens ...Lens) Segment {
+	re := "^"
+	for i, literal := range strings.Split(expr, ":") {
+		if i != 0 {
+			re += "(.+)"
+		}
+		re += regexp.QuoteMeta(literal)
+	}
+	re += "$"
+
+	if n := strings.Count(expr, ":"); n != len(lens) {
+		panic("pattern " + expr + " requires " + strconv.Itoa(n) + " lenses")
+	}
+
+	return Segment{
+		path:    ":",
+		pattern: &pattern{expr: expr, re: regexp.MustCompile(re), lens: lens},
+	}
+}
+
+// pattern of partial-segment
+type pattern struct {
+	expr string
+	re   *regexp.Regexp
+	lens []Lens
+}
+
+/*
+Constraint of wildcard path segment. The router matches the segment only
+if its value satisfies the constraint, siblings are tried otherwise.
+The name and predicate are required, routes share the wildcard node if
+constraints have same name, it panics if their predicates differ.
+*/
+type Constraint struct {
+	Name    string            // unique name of the constraint, e.g. int
+	Test    func(string) bool // predicate over the segment value
+	Samples []string          // values satisfying the constraint, used by ValidateRoutes
+
+	id string // identity of combined predicates, see allOf
+}
+
+// identity of constraint predicate, routes share the wildcard node
+// if constraints have same name and predicate
+func (c *Constraint) identity() string {
+	if c.id != "" {
+		return c.id
+	}
+	return strconv.FormatUint(uint64(reflect.ValueOf(c.Test).Pointer()), 16)
+}
+
+// IsInt constraints the segment to decimal integers
+func IsInt() Constraint {
+	return Constraint{Name: "int", Test: isInt, Samples: []string{"0", "1", "-1", "42"}}
+}
+
+func isInt(s string) bool {
+	if len(s) > 1 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+
+	if len(s) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsUUID constraints the segment to UUID in canonical textual form
+func IsUUID() Constraint {
+	return Constraint{
+		Name: "uuid",
+		Test: isUUID,
+		Samples: []string{
+			"00000000-0000-0000-0000-000000000000",
+			"ffffffff-ffff-ffff-ffff-ffffffffffff",
+		},
+	}
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			c := s[i]
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// IsRegexp constraints the segment to values matching regular expression
+func IsRegexp(expr string) Constraint {
+	re := regexp.MustCompile(expr)
+	return Constraint{Name: "regexp " + expr, Test: re.MatchString}
+}
+
+// IsOneOf constraints the segment to enumeration of literals
+func IsOneOf(seq ...string) Constraint {
+	if len(seq) == 0 {
+		panic("enumeration of literals is empty")
+	}
+
+	return Constraint{
+		Name:    "oneof " + strings.Join(seq, "|"),
+		Test:    func(s string) bool { return contains(seq, s) },
+		Samples: seq,
+	}
+}
+
+// allOf combines sequence of constraints, constraint without name or
+// predicate panics, the router cannot tell it apart from other wildcards
+func allOf(seq []Constraint) *Constraint {
+	for _, c := range seq {
+		if c.Name == "" || c.Test == nil {
+			panic("constraint requires name and predicate")
+		}
+	}
+
+	switch len(seq) {
+	case 0:
+		return nil
+	case 1:
+		return &seq[0]
+	}
+
+	names := make([]string, len(seq))
+	ids := make([]string, len(seq))
+	for i := range seq {
+		names[i] = seq[i].Name
+		ids[i] = seq[i].identity()
+	}
+
+	test := func(s string) bool {
+		for _, c := range seq {
+			if !c.Test(s) {
+				return false
+			}
+		}
+		return true
+	}
+
+	samples := []string{}
+	for _, c := range seq {
+		for _, x := range c.Samples {
+			if test(x) && !contains(samples, x) {
+				samples = append(samples, x)
+			}
+		}
+	}
+
+	return &Constraint{
+		Name:    strings.Join(names, ","),
+		Test:    test,
+		Samples: samples,
+		id:      strings.Join(ids, ","),
+	}
+}
+
+// kind of the trie node that matches the segment
+func (segment Segment) kind(last bool) byte {
+	switch {
+	case segment.pattern != nil:
+		return nodeLens
+	case segment.path == Any:
+		return nodeAny
+	case segment.optics == nil:
+		return nodeLiteral
+	case last && segment.path == "*":
+		return nodeAll
+	default:
+		return nodeLens
+	}
+}
+
+// name of segment constraint, wildcards with same kind and name
+// shares the trie node
+func (segment Segment) name() string {
+	switch {
+	case segment.pattern != nil:
+		return segment.pattern.expr
+	case segment.is != nil:
+		return segment.is.Name
+	default:
+		return ""
+	}
+}
+
+// identity of segment constraint, empty if constraint is not defined
+func (segment Segment) identity() string {
+	if segment.is == nil {
+		return ""
+	}
+	return segment.is.identity()
+}
+
+// test of segment value, it captures value(s) of segment if succeeded
+func (segment Segment) test() matcher {
+	switch {
+	case segment.pattern != nil:
+		re := segment.pattern.re
+		return func(s string, values *[]string) bool {
+			match := re.FindStringSubmatch(s)
+			if match == nil {
+				return false
+			}
+			*values = append(*values, match[1:]...)
+			return true
+		}
+	case segment.is != nil && segment.optics != nil:
+		is := segment.is.Test
+		return func(s string, values *[]string) bool {
+			if !is(s) {
+				return false
+			}
+			*values = append(*values, s)
+			return true
+		}
+	case segment.is != nil:
+		is := segment.is.Test
+		return func(s string, _ *[]string) bool { return is(s) }
+	default:
+		return nil
+	}
+}
+
+func segmentsToLens(segments []Segment) []optics.Lens {
 	lens := make([]optics.Lens, 0)
-	path := make([]string, 0, len(segments))
-	for i, segment := range segments {
-		if segment.optics == nil {
-			path = append(path, segment.path)
-		} else {
-			if i == len(segments)-1 && segment.path == "*" {
-				path = append(path, "*")
-			} else {
-				path = append(path, ":")
+	for _, segment := range segments {
+		switch {
+		case segment.pattern != nil:
+			for _, l := range segment.pattern.lens {
+				lens = append(lens, l)
 			}
-			lens = append(lens, segment.optics.Lens)
+		case segment.optics != nil:
+			lens = append(lens, *segment.optics)
 		}
 	}
 
-	return path, lens
+	return lens
 }
 
-func segmentsToEndpoint(path []string, lens []optics.Lens) Endpoint {
+func segmentsToEndpoint(lens []optics.Lens) Endpoint {
+	names := make([]string, len(lens))
+	decoders := make([]optics.Decoder, len(lens))
+	for i, l := range lens {
+		if l, ok := l.(Lens); ok {
+			names[i] = fieldName(l.field)
+		}
+		decoders[i] = decoderOf(l)
+	}
+
 	return func(ctx *Context) error {
 		if len(ctx.values) != len(lens) {
 			return ErrNoMatch
 		}
 
 		for i, l := range lens {
-			if err := ctx.Put(l, ctx.values[i]); err != nil {
-				return err
+			if err := ctx.decode(l, decoders[i], ctx.values[i]); err != nil {
+				return NoMatch{
+					Status: http.StatusBadRequest,
+					Source: "path",
+					Key:    names[i],
+					Reason: reasonInvalid,
+				}
 			}
 		}
 
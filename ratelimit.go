@@ -0,0 +1,214 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+RateLimitKey identifies the client of HTTP request for rate limiting.
+Requests without key (e.g. header is not defined) share the same quota.
+*/
+type RateLimitKey func(*Context) string
+
+// RateLimitByAddr identifies client by remote address
+func RateLimitByAddr(ctx *Context) string {
+	if ctx.Request == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(ctx.Request.RemoteAddr)
+	if err != nil {
+		return ctx.Request.RemoteAddr
+	}
+	return host
+}
+
+/*
+RateLimitByJWT identifies client by the claim of JWT
+
+	µ.RateLimitByJWT(µ.Token.Sub)
+	µ.RateLimitByJWT(µ.Token.ClientID)
+*/
+func RateLimitByJWT(claim JWTClaim) RateLimitKey {
+	return func(ctx *Context) string {
+		if ctx.JWT == nil {
+			return ""
+		}
+		return claim(ctx.JWT)
+	}
+}
+
+// RateLimitByHeader identifies client by the value of header
+func RateLimitByHeader(header string) RateLimitKey {
+	return func(ctx *Context) string {
+		if ctx.Request == nil {
+			return ""
+		}
+		return ctx.Request.Header.Get(header)
+	}
+}
+
+/*
+Quota is the state of client's quota after the request
+*/
+type Quota struct {
+	Allowed    bool          // request is allowed
+	Limit      int           // number of requests allowed within the window
+	Remaining  int           // number of requests remaining within the window
+	Reset      time.Duration // time until the quota is fully restored
+	RetryAfter time.Duration // time until next request is allowed
+}
+
+/*
+RateLimiter is the storage of client's quotas. The in-memory limiter is
+created by NewRateLimiter, implement the interface to share the quota
+among instances of the service (e.g. with Redis).
+*/
+type RateLimiter interface {
+	Take(ctx context.Context, key string) (Quota, error)
+}
+
+/*
+RateLimit is an endpoint that throttles requests of the client identified
+by the key. The request over the limit is answered with 429 Too Many Requests
+with Retry-After and RateLimit-* headers.
+
+	limiter := µ.NewRateLimiter(100, time.Minute)
+
+	µ.GET(
+		µ.URI(µ.Path("users")),
+		µ.RateLimit(limiter, µ.RateLimitByJWT(µ.Token.Sub)),
+	)
+
+The limiter failure is answered with 503 Service Unavailable.
+*/
+func RateLimit(limiter RateLimiter, key RateLimitKey) Endpoint {
+	return func(ctx *Context) error {
+		quota, err := limiter.Take(ctx, key(ctx))
+		if err != nil {
+			out := NewOutput(http.StatusServiceUnavailable)
+			out.SetIssue(fmt.Errorf("rate limiter failed: %w", err))
+			return out
+		}
+
+		if quota.Allowed {
+			return nil
+		}
+
+		out := NewOutput(http.StatusTooManyRequests)
+		out.SetHeader("Retry-After", seconds(quota.RetryAfter))
+		out.SetHeader("RateLimit-Limit", strconv.Itoa(quota.Limit))
+		out.SetHeader("RateLimit-Remaining", strconv.Itoa(quota.Remaining))
+		out.SetHeader("RateLimit-Reset", seconds(quota.Reset))
+		out.SetIssue(fmt.Errorf("rate limit %d is exceeded", quota.Limit))
+		return out
+	}
+}
+
+// seconds formats duration as delay-seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+/*
+NewRateLimiter creates in-memory token bucket limiter. Each client is
+allowed to burst limit requests, the quota is restored at rate of limit
+requests per window.
+
+	µ.NewRateLimiter(100, time.Minute)
+*/
+func NewRateLimiter(limit int, window time.Duration) RateLimiter {
+	return &tokenBucket{
+		limit:   limit,
+		window:  window,
+		rate:    float64(limit) / window.Seconds(),
+		buckets: map[string]*bucket{},
+	}
+}
+
+type tokenBucket struct {
+	sync.Mutex
+	limit   int
+	window  time.Duration
+	rate    float64 // tokens per second
+	swept   time.Time
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	seen   time.Time
+}
+
+func (tb *tokenBucket) Take(_ context.Context, key string) (Quota, error) {
+	tb.Lock()
+	defer tb.Unlock()
+
+	now := time.Now()
+	tb.sweep(now)
+
+	b, has := tb.buckets[key]
+	if !has {
+		b = &bucket{tokens: float64(tb.limit), seen: now}
+		tb.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(tb.limit), b.tokens+now.Sub(b.seen).Seconds()*tb.rate)
+	b.seen = now
+
+	quota := Quota{Limit: tb.limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		quota.Allowed = true
+	} else {
+		quota.RetryAfter = tb.duration(1 - b.tokens)
+	}
+
+	quota.Remaining = int(b.tokens)
+	quota.Reset = tb.duration(float64(tb.limit) - b.tokens)
+	return quota, nil
+}
+
+// duration to restore given number of tokens
+func (tb *tokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / tb.rate * float64(time.Second))
+}
+
+// sweep removes buckets of idle clients, their quota is fully restored
+func (tb *tokenBucket) sweep(now time.Time) {
+	if now.Sub(tb.swept) < tb.window {
+		return
+	}
+
+	for key, b := range tb.buckets {
+		if now.Sub(b.seen) >= tb.window {
+			delete(tb.buckets, key)
+		}
+	}
+	tb.swept = now
+}
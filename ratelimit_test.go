@@ -0,0 +1,98 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+func TestRateLimit(t *testing.T) {
+	foo := µ.RateLimit(µ.NewRateLimiter(2, time.Hour), µ.RateLimitByAddr)
+	req := func(addr string) *µ.Context {
+		return mock.Input(mock.RemoteAddr(addr))
+	}
+
+	it.Then(t).Should(
+		it.Nil(foo(req("10.0.0.1:1234"))),
+		it.Nil(foo(req("10.0.0.1:1235"))),
+		it.Nil(foo(req("10.0.0.2:1234"))),
+	)
+
+	err := foo(req("10.0.0.1:1236"))
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(err, http.StatusTooManyRequests)),
+		it.Equal(err.(*µ.Output).GetHeader("Retry-After"), "1800"),
+		it.Equal(err.(*µ.Output).GetHeader("RateLimit-Limit"), "2"),
+		it.Equal(err.(*µ.Output).GetHeader("RateLimit-Remaining"), "0"),
+		it.Equal(err.(*µ.Output).GetHeader("RateLimit-Reset"), "3600"),
+		it.Equal(err.(*µ.Output).GetHeader("Content-Type"), "application/json"),
+	)
+}
+
+func TestRateLimitKey(t *testing.T) {
+	t.Run("JWT", func(t *testing.T) {
+		foo := µ.RateLimit(µ.NewRateLimiter(1, time.Hour), µ.RateLimitByJWT(µ.Token.Sub))
+		req := func(sub string) *µ.Context {
+			return mock.Input(mock.JWT(µ.Token{"sub": sub}))
+		}
+
+		it.Then(t).Should(
+			it.Nil(foo(req("joe"))),
+			it.Nil(foo(req("doe"))),
+			it.Nil(mock.CheckStatusCode(foo(req("joe")), http.StatusTooManyRequests)),
+		)
+	})
+
+	t.Run("Header", func(t *testing.T) {
+		foo := µ.RateLimit(µ.NewRateLimiter(1, time.Hour), µ.RateLimitByHeader("X-Api-Key"))
+		req := func(key string) *µ.Context {
+			return mock.Input(mock.Header("X-Api-Key", key))
+		}
+
+		it.Then(t).Should(
+			it.Nil(foo(req("a"))),
+			it.Nil(foo(req("b"))),
+			it.Nil(mock.CheckStatusCode(foo(req("a")), http.StatusTooManyRequests)),
+			it.Nil(foo(mock.Input())),
+			it.Nil(mock.CheckStatusCode(foo(mock.Input()), http.StatusTooManyRequests)),
+		)
+	})
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Take(context.Context, string) (µ.Quota, error) {
+	return µ.Quota{}, errors.New("store is not available")
+}
+
+func TestRateLimitFailure(t *testing.T) {
+	foo := µ.RateLimit(failingLimiter{}, µ.RateLimitByAddr)
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(foo(mock.Input()), http.StatusServiceUnavailable)),
+	)
+}
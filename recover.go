@@ -0,0 +1,57 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+/*
+Recover converts panic of the endpoint into 500 Internal Server Error.
+The response carries RFC 7807 Issue, the failure of output refers to
+the issue ID and holds the stack trace of the panic so that servers log it.
+Both servers recover panics by default, use the combinator to isolate
+a particular endpoint (e.g. within co-product).
+
+	µ.GET(
+		µ.URI(µ.Path("users")),
+		µ.Recover(µ.FMap(func(ctx *µ.Context, req *MyT) error { ... })),
+	)
+
+The panic with http.ErrAbortHandler is not recovered, it aborts the request.
+*/
+func Recover(endpoint Endpoint) Endpoint {
+	return func(ctx *Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				out := NewOutput(http.StatusInternalServerError)
+				out.SetIssue(fmt.Errorf("panic: %v\n%s", r, debug.Stack()))
+				err = out
+			}
+		}()
+
+		return endpoint(ctx)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+func TestRecover(t *testing.T) {
+	foo := µ.Recover(func(ctx *µ.Context) error { panic("boom") })
+	err := foo(mock.Input())
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(err, http.StatusInternalServerError)),
+		it.Equal(err.(*µ.Output).GetHeader("Content-Type"), "application/json"),
+		it.String(err.(*µ.Output).Failure.Error()).Contain("panic: boom"),
+		it.String(err.(*µ.Output).Failure.Error()).Contain("recover_test.go"),
+	)
+}
+
+func TestRecoverNoPanic(t *testing.T) {
+	foo := µ.Recover(func(ctx *µ.Context) error { return µ.ErrNoMatch })
+
+	it.Then(t).Should(
+		it.Equal(foo(mock.Input()), µ.ErrNoMatch),
+	)
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	foo := µ.Recover(func(ctx *µ.Context) error { panic(http.ErrAbortHandler) })
+
+	defer func() {
+		it.Then(t).Should(
+			it.Equal(recover(), any(http.ErrAbortHandler)),
+		)
+	}()
+
+	foo(mock.Input())
+}
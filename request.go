@@ -19,7 +19,6 @@
 package gouldian
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"unsafe"
@@ -31,16 +30,37 @@ const (
 )
 
 /*
-Route converts sequence ot Endpoints into Routable element
+Route converts sequence ot Endpoints into Routable element.
+The router does not know HTTP verb of the element, use HTTP or
+its variants if the verb is required for routing decisions.
+The group of routes (see Mount) cannot be composed with endpoints,
+it panics at registration. Use the prefix of Mount to share guards.
 */
 func Route(
 	path Routable,
 	seq ...Endpoint,
 ) Routable {
-	return func() ([]string, Endpoint) {
-		route, pathEndpoint := path()
-		endpoints := append(Endpoints{pathEndpoint}, seq...)
-		return route, endpoints.Join
+	return func() Spec {
+		spec := path()
+		if spec.Group != nil {
+			panic("group of routes cannot be composed with endpoints or verb, use prefix of µ.Mount")
+		}
+
+		endpoints := append(Endpoints{spec.Func}, seq...)
+		spec.Func = endpoints.Join
+		for i, endpoint := range seq {
+			decl, ok := declarationOf(endpoint)
+			if !ok || decl.guard {
+				spec.guard++
+			}
+			if len(decl.query) != 0 {
+				spec.query = append(append([]ReverseQuery{}, spec.query...), decl.query...)
+			}
+			if i == len(seq)-1 {
+				spec.handler = !ok
+			}
+		}
+		return spec
 	}
 }
 
@@ -55,8 +75,7 @@ DELETE composes Endpoints into Routable that matches HTTP DELETE request.
 	e(mock.Input(mock.Method("OTHER"))) != nil
 */
 func DELETE(path Routable, arrows ...Endpoint) Routable {
-	seq := append(Endpoints{Method("DELETE")}, arrows...)
-	return Route(path, seq...)
+	return HTTP(http.MethodDelete, path, arrows...)
 }
 
 /*
@@ -70,8 +89,7 @@ GET composes Endpoints into Routable that matches HTTP GET request.
 	e(mock.Input(mock.Method("OTHER"))) != nil
 */
 func GET(path Routable, arrows ...Endpoint) Routable {
-	seq := append(Endpoints{Method("GET")}, arrows...)
-	return Route(path, seq...)
+	return HTTP(http.MethodGet, path, arrows...)
 }
 
 /*
@@ -85,8 +103,7 @@ PATCH composes Endpoints into Routable that matches HTTP PATCH request.
 	e(mock.Input(mock.Method("OTHER"))) != nil
 */
 func PATCH(path Routable, arrows ...Endpoint) Routable {
-	seq := append(Endpoints{Method("PATCH")}, arrows...)
-	return Route(path, seq...)
+	return HTTP(http.MethodPatch, path, arrows...)
 }
 
 /*
@@ -100,8 +117,7 @@ POST composes Endpoints into Routable that matches HTTP POST request.
 	e(mock.Input(mock.Method("OTHER"))) != nil
 */
 func POST(path Routable, arrows ...Endpoint) Routable {
-	seq := append(Endpoints{Method("POST")}, arrows...)
-	return Route(path, seq...)
+	return HTTP(http.MethodPost, path, arrows...)
 }
 
 /*
@@ -115,8 +131,7 @@ PUT composes Endpoints into Routable that matches HTTP PUT request.
 	e(mock.Input(mock.Method("OTHER"))) != nil
 */
 func PUT(path Routable, arrows ...Endpoint) Routable {
-	seq := append(Endpoints{Method("PUT")}, arrows...)
-	return Route(path, seq...)
+	return HTTP(http.MethodPut, path, arrows...)
 }
 
 /*
@@ -130,25 +145,30 @@ ANY composes Endpoints into Routable that matches HTTP any request.
 	e(mock.Input(mock.Method("OTHER"))) == nil
 */
 func ANY(path Routable, arrows ...Endpoint) Routable {
-	seq := append(Endpoints{Method(Any)}, arrows...)
-	return Route(path, seq...)
+	return HTTP(Any, path, arrows...)
 }
 
 // HTTP composes Endpoints into Routable
 func HTTP(verb string, path Routable, arrows ...Endpoint) Routable {
 	seq := append(Endpoints{Method(verb)}, arrows...)
-	return Route(path, seq...)
+	route := Route(path, seq...)
+
+	return func() Spec {
+		spec := route()
+		spec.Method = verb
+		return spec
+	}
 }
 
 // Method is an endpoint to match HTTP verb request
 func Method(verb string) Endpoint {
 	if verb == Any {
-		return func(ctx *Context) error {
+		return declare(declaration{}, func(ctx *Context) error {
 			return nil
-		}
+		})
 	}
 
-	return func(ctx *Context) error {
+	return declare(declaration{}, func(ctx *Context) error {
 		if ctx.Request == nil {
 			return ErrNoMatch
 		}
@@ -158,7 +178,7 @@ func Method(verb string) Endpoint {
 		}
 
 		return ErrNoMatch
-	}
+	})
 }
 
 // Body decodes HTTP request body and lifts it to the structure
@@ -170,10 +190,21 @@ func Body(lens Lens) Endpoint {
 			}
 
 			if ctx.payload == nil {
-				return ErrNoMatch
+				return NoMatch{
+					Status: http.StatusBadRequest,
+					Source: "body",
+					Reason: reasonMissing,
+				}
 			}
 
-			return ctx.Put(lens, *(*string)(unsafe.Pointer(&ctx.payload)))
+			if err := ctx.Put(lens, *(*string)(unsafe.Pointer(&ctx.payload))); err != nil {
+				return NoMatch{
+					Status: http.StatusBadRequest,
+					Source: "body",
+					Reason: reasonInvalid,
+				}
+			}
+			return nil
 		}
 
 		return ErrNoMatch
@@ -181,9 +212,12 @@ func Body(lens Lens) Endpoint {
 }
 
 // FMap applies clojure to matched HTTP request,
-// taking the execution context as the input to closure
+// taking the execution context as the input to closure.
+// The input is validated before the closure, see Validator.
 func FMap[A any](f func(*Context, *A) error) Endpoint {
-	return func(req *Context) error {
+	rules := validatorOf[A]()
+
+	return declare(declaration{}, func(req *Context) error {
 		var a A
 		if err := FromContext(req, &a); err != nil {
 			out := NewOutput(http.StatusBadRequest)
@@ -191,15 +225,23 @@ func FMap[A any](f func(*Context, *A) error) Endpoint {
 			return out
 		}
 
+		if err := rules.check(&a); err != nil {
+			return err
+		}
+
 		return f(req, &a)
-	}
+	})
 }
 
 // Map applies clojure to matched HTTP request,
 // taking the execution context and matched parameters as the input to closure.
-// The output is always returned as JSON.
+// The input is validated before the closure, see Validator.
+// The output is encoded to media type accepted by the client, see RegisterCodec.
+// The closure is not applied if none of media types is acceptable.
 func Map[A, B any](f func(*Context, *A) (*B, error)) Endpoint {
-	return func(req *Context) error {
+	rules := validatorOf[A]()
+
+	return declare(declaration{}, func(req *Context) error {
 		var a A
 		if err := FromContext(req, &a); err != nil {
 			out := NewOutput(http.StatusBadRequest)
@@ -207,21 +249,31 @@ func Map[A, B any](f func(*Context, *A) (*B, error)) Endpoint {
 			return out
 		}
 
+		if err := rules.check(&a); err != nil {
+			return err
+		}
+
+		seq := *codecs.Load()
+		codec, ok := negotiate(req.Request.Header.Get("Accept"), seq)
+		if !ok {
+			return notAcceptable(seq)
+		}
+
 		b, err := f(req, &a)
 		if err != nil {
 			return err
 		}
 
-		out := NewOutput(http.StatusOK)
-
-		val, err := json.Marshal(b)
+		val, err := codec.Encode(b)
 		if err != nil {
-			out.SetIssue(fmt.Errorf("serialization is failed for <%T>", val))
+			out := NewOutput(http.StatusInternalServerError)
+			out.SetIssue(fmt.Errorf("serialization is failed for <%T>: %w", b, err))
 			return out
 		}
 
-		out.SetHeader("Content-Type", "application/json")
+		out := NewOutput(http.StatusOK)
+		out.SetHeader("Content-Type", codec.MediaType)
 		out.Body = string(val)
 		return out
-	}
+	})
 }
@@ -0,0 +1,285 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+/*
+Name assigns the name to the route, named routes are discoverable
+at the routing table (see Node.Named) for reverse routing.
+
+	µ.Name("user", µ.GET(µ.URI(µ.Path("users"), µ.Path(id))))
+
+The group of routes (see Mount) cannot be named, it panics at registration.
+*/
+func Name(name string, route Routable) Routable {
+	return func() Spec {
+		spec := route()
+		if spec.Group != nil {
+			panic("group of routes cannot be named, name routes of the group")
+		}
+
+		spec.Name = name
+		return spec
+	}
+}
+
+/*
+Named returns the route registered with the name, nil if the route is
+not known. It is primary used with reverse routing
+
+	µ.MustReverse[User](router.Named("user"))
+*/
+func (root *Node) Named(name string) Routable {
+	var route Routable
+	root.Walk(func(_ int, node *Node) {
+		for _, spec := range node.Spec {
+			if route == nil && spec.Name == name {
+				spec := spec
+				route = func() Spec { return spec }
+			}
+		}
+	})
+
+	return route
+}
+
+/*
+Reverse routing renders URL of the route from the value of type S, it uses
+same lenses in opposite direction: the value of struct field focused by lens
+becomes the path segment or query parameter. Query parameters are ones
+matched by Param, ParamAny and ParamMaybe of the route (including Bind),
+QueryParam and QueryParamMaybe declare others or override them.
+*/
+type Reverse[S any] struct {
+	segments []Segment
+	query    []ReverseQuery
+}
+
+/*
+ReverseQuery declares query parameter rendered by reverse routing.
+*/
+type ReverseQuery struct {
+	key   string
+	value string // literal value of parameter
+	lens  *Lens  // lens focused on value of parameter
+	maybe bool   // empty value is omitted
+}
+
+/*
+QueryParam declares query parameter that is not known to the route
+(e.g. matched by custom endpoint) or overrides the one matched by Param.
+It is either literal value or lens. The empty value of lens fails rendering
+of URL.
+
+	µ.MustReverse[User](route,
+		µ.QueryParam("view", "full"),
+		µ.QueryParam("tenant", tenant),
+	)
+*/
+func QueryParam[T Pattern](key string, val T) ReverseQuery {
+	switch v := any(val).(type) {
+	case string:
+		return ReverseQuery{key: key, value: v}
+	case Lens:
+		_, maybe := v.field.Tag.Lookup("default")
+		return ReverseQuery{key: key, lens: &v, maybe: maybe}
+	default:
+		panic("type system failure")
+	}
+}
+
+// QueryParamMaybe declares optional query parameter, the empty value is
+// omitted. It overrides the parameter matched by the route.
+func QueryParamMaybe(key string, lens Lens) ReverseQuery {
+	return ReverseQuery{key: key, lens: &lens, maybe: true}
+}
+
+/*
+NewReverse builds reverse routing for the route and its query parameters.
+It fails if the route cannot be reversed: path has wildcard segments that
+are not lenses, param is wildcard (e.g. ParamAny) or lenses focuses on types
+other than S.
+*/
+func NewReverse[S any](route Routable, query ...ReverseQuery) (*Reverse[S], error) {
+	if route == nil {
+		return nil, errors.New("route is not defined")
+	}
+
+	spec := route()
+	if spec.Group != nil {
+		return nil, errors.New("group of routes cannot be reversed")
+	}
+
+	target := reflect.TypeOf(new(S)).Elem()
+	for _, segment := range spec.Segments {
+		switch {
+		case segment.pattern != nil:
+			for i := range segment.pattern.lens {
+				if err := reversible(target, &segment.pattern.lens[i]); err != nil {
+					return nil, err
+				}
+			}
+		case segment.optics != nil:
+			if err := reversible(target, segment.optics); err != nil {
+				return nil, err
+			}
+		case segment.path == Any:
+			return nil, fmt.Errorf("wildcard %s cannot be reversed", Any)
+		}
+	}
+
+	seq := make([]ReverseQuery, 0, len(spec.query)+len(query))
+	for _, q := range spec.query {
+		if !hasQueryParam(query, q.key) {
+			seq = append(seq, q)
+		}
+	}
+	seq = append(seq, query...)
+
+	for _, q := range seq {
+		if q.lens == nil && (q.value == "" || q.value == Any) {
+			return nil, fmt.Errorf("wildcard param %s cannot be reversed", q.key)
+		}
+
+		if q.lens != nil {
+			if err := reversible(target, q.lens); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Reverse[S]{segments: spec.Segments, query: seq}, nil
+}
+
+func hasQueryParam(seq []ReverseQuery, key string) bool {
+	for _, q := range seq {
+		if q.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+MustReverse builds reverse routing for the route, it panics if the route
+cannot be reversed. Use it to fail at startup.
+
+	var userURL = µ.MustReverse[User](
+		µ.GET(µ.URI(µ.Path("users"), µ.Path(id))),
+	)
+
+	userURL.URL(User{ID: "1"}) // "/users/1"
+*/
+func MustReverse[S any](route Routable, query ...ReverseQuery) *Reverse[S] {
+	reverse, err := NewReverse[S](route, query...)
+	if err != nil {
+		panic(err)
+	}
+	return reverse
+}
+
+func reversible(target reflect.Type, lens *Lens) error {
+	if lens.target != target {
+		return fmt.Errorf("lens focuses %v, route cannot be reversed from %v", lens.target, target)
+	}
+	return nil
+}
+
+// URL renders path and query string of the route from the value
+func (reverse *Reverse[S]) URL(val S) (string, error) {
+	path := make([]string, len(reverse.segments))
+	for i, segment := range reverse.segments {
+		switch {
+		case segment.pattern != nil:
+			literals := strings.Split(segment.pattern.expr, ":")
+			for k := range segment.pattern.lens {
+				value, err := segment.pattern.lens[k].ToString(&val)
+				if err != nil {
+					return "", err
+				}
+				literals[k+1] = url.PathEscape(value) + literals[k+1]
+			}
+			path[i] = strings.Join(literals, "")
+		case segment.optics != nil:
+			value, err := segment.optics.ToString(&val)
+			if err != nil {
+				return "", err
+			}
+
+			if segment.is != nil && !segment.is.Test(value) {
+				return "", fmt.Errorf("path segment %q violates constraint %s", value, segment.is.Name)
+			}
+
+			if segment.kind(i == len(reverse.segments)-1) == nodeAll {
+				seq := strings.Split(value, "/")
+				for k := range seq {
+					seq[k] = url.PathEscape(seq[k])
+				}
+				value = strings.Join(seq, "/")
+			} else {
+				value = url.PathEscape(value)
+			}
+			path[i] = value
+		default:
+			path[i] = url.PathEscape(segment.path)
+		}
+
+		if path[i] == "" {
+			return "", fmt.Errorf("path segment #%d is empty", i)
+		}
+	}
+
+	uri := "/" + strings.Join(path, "/")
+	if len(reverse.query) == 0 {
+		return uri, nil
+	}
+
+	query := url.Values{}
+	for _, q := range reverse.query {
+		if q.lens == nil {
+			query.Add(q.key, q.value)
+			continue
+		}
+
+		value, err := q.lens.ToString(&val)
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case value != "":
+			query.Add(q.key, value)
+		case !q.maybe:
+			return "", fmt.Errorf("param %s is empty", q.key)
+		}
+	}
+
+	if len(query) == 0 {
+		return uri, nil
+	}
+
+	return uri + "?" + query.Encode(), nil
+}
@@ -0,0 +1,172 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/it/v2"
+)
+
+type reverseT struct {
+	Tenant string
+	ID     int
+	Path   string
+	Name   string
+	Ext    string
+	Limit  *int
+}
+
+var (
+	rTenant, rID, rPath = µ.Optics3[reverseT, string, int, string]("Tenant", "ID", "Path")
+	rName, rExt, rLimit = µ.Optics3[reverseT, string, string, *int]("Name", "Ext", "Limit")
+)
+
+func TestReverse(t *testing.T) {
+	limit := 10
+
+	for expect, reverse := range map[string]*µ.Reverse[reverseT]{
+		"/users/a%20b/10": µ.MustReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(rTenant), µ.Path(rID, µ.IsInt()))),
+		),
+		"/users/a%20b?limit=10": µ.MustReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(rTenant)), µ.ParamMaybe("limit", rLimit)),
+		),
+		"/users/a%20b?name=report&view=full": µ.MustReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(rTenant)), µ.Param("view", "full"), µ.Param("name", rName)),
+		),
+		"/users?tenant=a+b&view=short": µ.MustReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("users")), µ.Param("tenant", rTenant), µ.Param("view", "full")),
+			µ.QueryParam("view", "short"),
+		),
+		"/users/a%20b?name=report": µ.MustReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(rTenant)), µ.Or(µ.Param("name", rName), µ.Param("ext", rExt))),
+			µ.QueryParam("name", rName),
+		),
+		"/static/a/b%20c": µ.MustReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("static"), µ.PathAll(rPath))),
+		),
+		"/files/report.pdf": µ.MustReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("files"), µ.PathPattern(":.:", rName, rExt))),
+		),
+	} {
+		url, err := reverse.URL(reverseT{
+			Tenant: "a b",
+			ID:     10,
+			Path:   "a/b c",
+			Name:   "report",
+			Ext:    "pdf",
+			Limit:  &limit,
+		})
+
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(url, expect),
+		)
+	}
+}
+
+func TestReverseFailure(t *testing.T) {
+	type otherT struct{ ID string }
+	id := µ.Optics1[otherT, string]()
+
+	t.Run("Wildcard", func(t *testing.T) {
+		_, err := µ.NewReverse[reverseT](µ.GET(µ.URI(µ.Path("users"), µ.PathAny())))
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+
+	t.Run("Type", func(t *testing.T) {
+		_, err := µ.NewReverse[reverseT](µ.GET(µ.URI(µ.Path("users"), µ.Path(id))))
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		routes := µ.NewRoutes(µ.GET(µ.URI(µ.Path("users"))))
+		_, err := µ.NewReverse[reverseT](routes.Named("user"))
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+
+	t.Run("WildcardParam", func(t *testing.T) {
+		_, err := µ.NewReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("users")), µ.ParamAny("q")),
+		)
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+
+	t.Run("TypeParam", func(t *testing.T) {
+		_, err := µ.NewReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("users")), µ.ParamMaybe("id", id)),
+		)
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+
+	t.Run("EmptyParam", func(t *testing.T) {
+		reverse := µ.MustReverse[reverseT](
+			µ.GET(µ.URI(µ.Path("users")), µ.Param("name", rName)),
+		)
+		_, err := reverse.URL(reverseT{})
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		reverse := µ.MustReverse[reverseT](µ.GET(µ.URI(µ.Path("users"), µ.Path(rTenant))))
+		_, err := reverse.URL(reverseT{})
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+}
+
+func TestReverseNamed(t *testing.T) {
+	routes := µ.NewRoutes(
+		µ.Mount(
+			µ.Route(µ.URI(µ.Path("api")), µ.ParamMaybe("name", rName)),
+			µ.Name("user", µ.GET(µ.URI(µ.Path("users"), µ.Path(rTenant)), µ.Param("view", "full"))),
+		),
+	)
+
+	reverse := µ.MustReverse[reverseT](routes.Named("user"))
+	url, err := reverse.URL(reverseT{Tenant: "a"})
+	named, _ := reverse.URL(reverseT{Tenant: "a", Name: "b"})
+
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(url, "/api/users/a?view=full"),
+		it.Equal(named, "/api/users/a?name=b&view=full"),
+		it.Equal(routes.Routes()[0].Name, "user"),
+	)
+}
+
+func TestReverseBind(t *testing.T) {
+	type T struct {
+		ID     string `path:"id"`
+		Tenant string `query:"tenant"`
+		Limit  int    `query:"limit,optional"`
+	}
+	req := µ.Bind[T]()
+
+	reverse := µ.MustReverse[T](µ.GET(req.URI("/users/:id"), req.Endpoint()))
+	url, err := reverse.URL(T{ID: "1", Tenant: "a", Limit: 10})
+	_, empty := reverse.URL(T{ID: "1"})
+
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(url, "/users/1?limit=10&tenant=a"),
+		it.Fail(func() error { return empty }),
+	)
+}
@@ -0,0 +1,206 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kinds of path segments
+const (
+	// SegmentLiteral matches segment to literal value
+	SegmentLiteral = "literal"
+	// SegmentLens lifts segment value to the context
+	SegmentLens = "lens"
+	// SegmentAny matches any segment value
+	SegmentAny = "any"
+	// SegmentAll lifts the remaining path to the context
+	SegmentAll = "all"
+	// SegmentPattern lifts parts of segment to the context
+	SegmentPattern = "pattern"
+)
+
+/*
+RouteInfo is a structured description of the route registered
+at the routing table.
+*/
+type RouteInfo struct {
+	Name     string            `json:"name,omitempty"`
+	Host     string            `json:"host,omitempty"`
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Segments []SegmentInfo     `json:"segments"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Policy   RoutePolicy       `json:"policy"`
+}
+
+/*
+RoutePolicy is the path normalization policy of routing table applied to the route
+*/
+type RoutePolicy struct {
+	TrailingSlash   string `json:"trailingSlash"`
+	CollapseSlashes bool   `json:"collapseSlashes,omitempty"`
+	CaseInsensitive bool   `json:"caseInsensitive,omitempty"`
+	RawPath         bool   `json:"rawPath,omitempty"`
+}
+
+/*
+SegmentInfo is a structured description of the path segment
+*/
+type SegmentInfo struct {
+	Kind       string        `json:"kind"`                 // kind of segment
+	Value      string        `json:"value,omitempty"`      // value of literal segment or pattern
+	Struct     string        `json:"struct,omitempty"`     // type of struct focused by lens
+	Field      string        `json:"field,omitempty"`      // field of struct focused by lens
+	Type       string        `json:"type,omitempty"`       // type of the field focused by lens
+	Constraint string        `json:"constraint,omitempty"` // constraint of segment value
+	Captures   []SegmentInfo `json:"captures,omitempty"`   // lenses of pattern placeholders
+}
+
+/*
+Annotate attaches metadata to the route. The metadata is not used by the
+router, it is available through the route introspection. Annotation of
+the group (see Mount) attaches metadata to each route of the group.
+
+	µ.Annotate(
+		µ.GET(µ.URI(µ.Path("users")), ...),
+		map[string]string{"summary": "list users"},
+	)
+*/
+func Annotate(route Routable, meta map[string]string) Routable {
+	return func() Spec {
+		spec := route()
+		spec.Meta = mergeMeta(spec.Meta, meta)
+		for i := range spec.Group {
+			spec.Group[i].Meta = mergeMeta(spec.Group[i].Meta, meta)
+		}
+
+		return spec
+	}
+}
+
+/*
+Routes lists every route registered at the routing table. The list is
+ordered by path and HTTP verb.
+*/
+func (root *Node) Routes() []RouteInfo {
+	policy := RoutePolicy{
+		TrailingSlash:   root.opts.trailingSlash.String(),
+		CollapseSlashes: root.opts.collapseSlashes,
+		CaseInsensitive: root.opts.caseInsensitive,
+		RawPath:         root.opts.rawPath,
+	}
+
+	seq := make([]RouteInfo, 0)
+	root.Walk(func(_ int, node *Node) {
+		for _, spec := range node.Spec {
+			info := spec.info()
+			info.Policy = policy
+			seq = append(seq, info)
+		}
+	})
+
+	sort.SliceStable(seq, func(i, j int) bool {
+		if seq[i].Path == seq[j].Path {
+			return seq[i].Method < seq[j].Method
+		}
+		return seq[i].Path < seq[j].Path
+	})
+
+	return seq
+}
+
+func (spec Spec) info() RouteInfo {
+	segments := make([]SegmentInfo, len(spec.Segments))
+	template := make([]string, len(spec.Segments))
+	for i, segment := range spec.Segments {
+		segments[i] = segment.info(i == len(spec.Segments)-1)
+
+		switch segments[i].Kind {
+		case SegmentLens:
+			template[i] = ":" + segments[i].Field
+		case SegmentAll:
+			template[i] = "*" + segments[i].Field
+		case SegmentAny:
+			template[i] = Any
+		case SegmentPattern:
+			literals := strings.Split(segments[i].Value, ":")
+			for k, capture := range segments[i].Captures {
+				literals[k+1] = capture.Field + literals[k+1]
+			}
+			template[i] = strings.Join(literals, ":")
+		default:
+			template[i] = segments[i].Value
+		}
+
+		if segments[i].Constraint != "" {
+			template[i] += "{" + segments[i].Constraint + "}"
+		}
+	}
+
+	return RouteInfo{
+		Name:     spec.Name,
+		Method:   spec.Method,
+		Path:     "/" + strings.Join(template, "/"),
+		Segments: segments,
+		Meta:     spec.Meta,
+	}
+}
+
+func (segment Segment) info(last bool) SegmentInfo {
+	info := SegmentInfo{}
+	if segment.is != nil {
+		info.Constraint = segment.is.Name
+	}
+
+	switch segment.kind(last) {
+	case nodeLiteral:
+		info.Kind = SegmentLiteral
+		info.Value = segment.path
+	case nodeAny:
+		info.Kind = SegmentAny
+	case nodeAll:
+		info.Kind = SegmentAll
+		info.lens(segment.optics)
+	default:
+		if segment.pattern != nil {
+			info.Kind = SegmentPattern
+			info.Value = segment.pattern.expr
+			info.Captures = make([]SegmentInfo, len(segment.pattern.lens))
+			for i := range segment.pattern.lens {
+				info.Captures[i] = SegmentInfo{Kind: SegmentLens}
+				info.Captures[i].lens(&segment.pattern.lens[i])
+			}
+		} else {
+			info.Kind = SegmentLens
+			info.lens(segment.optics)
+		}
+	}
+
+	return info
+}
+
+func (info *SegmentInfo) lens(optics *Lens) {
+	if optics.target != nil {
+		info.Struct = optics.target.String()
+		info.Field = optics.field.Name
+		info.Type = optics.field.Type.String()
+	}
+}
@@ -34,8 +34,14 @@ import (
 
 // Request is events.APIGatewayProxyRequest ⟼ µ.Input
 func Request(r *events.APIGatewayProxyRequest) *µ.Context {
-	ctx := µ.NewContext(context.Background())
-	req, err := http.NewRequest(r.HTTPMethod, r.Path, requestBody(r))
+	return RequestContext(context.Background(), r)
+}
+
+// RequestContext is events.APIGatewayProxyRequest ⟼ µ.Input, the input
+// is bound with the context of Lambda invocation
+func RequestContext(parent context.Context, r *events.APIGatewayProxyRequest) *µ.Context {
+	ctx := µ.NewContext(parent)
+	req, err := http.NewRequestWithContext(parent, r.HTTPMethod, r.Path, requestBody(r))
 	if err != nil {
 		return nil
 	}
@@ -44,6 +50,12 @@ func Request(r *events.APIGatewayProxyRequest) *µ.Context {
 		req.Header.Set(header, value)
 	}
 
+	req.RemoteAddr = r.RequestContext.Identity.SourceIP
+	req.Host = req.Header.Get("Host")
+	if req.Host == "" {
+		req.Host = r.RequestContext.DomainName
+	}
+
 	q := req.URL.Query()
 	for key, val := range r.QueryStringParameters {
 		q.Add(key, val)
@@ -92,6 +104,8 @@ func jwtFromAuthorizer(r *events.APIGatewayProxyRequest) µ.Token {
 
 }
 
+// ServeAndCommit serves HTTP service, commit is called after each request.
+// The context of Lambda invocation is not propagated, use ServeAndCommitContext.
 func ServeAndCommit(commit func(), endpoints ...µ.Routable) func(events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	h := Serve(endpoints...)
 
@@ -102,12 +116,52 @@ func ServeAndCommit(commit func(), endpoints ...µ.Routable) func(events.APIGate
 	}
 }
 
-// Serve HTTP service
+// Serve HTTP service. The handler does not accept the context of Lambda
+// invocation, its deadline and cancellation are lost: µ.Timeout is not
+// cancelled by Lambda timeout. Use ServeContext to propagate them.
 func Serve(endpoints ...µ.Routable) func(events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	api := µ.NewRoutes(endpoints...).Endpoint()
+	return ServeRouter(µ.NewRoutes(endpoints...))
+}
+
+// ServeRouter serves HTTP service using the routing table,
+// interceptors are applied to every request, panics are recovered
+// with 500 Internal Server Error. The context of Lambda invocation
+// is not propagated, use ServeRouterContext.
+func ServeRouter(router µ.Router, seq ...µ.Interceptor) func(events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	h := ServeRouterContext(router, seq...)
 
 	return func(r events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-		req := Request(&r)
+		return h(context.Background(), r)
+	}
+}
+
+// ServeContext serves HTTP service, the context of Lambda invocation
+// (e.g. deadline) is propagated to endpoints
+//
+//	lambda.Start(apigateway.ServeContext( ... ))
+func ServeContext(endpoints ...µ.Routable) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return ServeRouterContext(µ.NewRoutes(endpoints...))
+}
+
+// ServeAndCommitContext serves HTTP service, commit is called after each
+// request. The context of Lambda invocation is propagated to endpoints.
+func ServeAndCommitContext(commit func(), endpoints ...µ.Routable) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	h := ServeContext(endpoints...)
+
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		ret, err := h(ctx, req)
+		commit()
+		return ret, err
+	}
+}
+
+// ServeRouterContext serves HTTP service using the routing table,
+// the context of Lambda invocation is propagated to endpoints
+func ServeRouterContext(router µ.Router, seq ...µ.Interceptor) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	api := µ.Recover(µ.InterceptRouter(router, seq...).Endpoint())
+
+	return func(ctx context.Context, r events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		req := RequestContext(ctx, &r)
 		if req == nil {
 			failure := ø.Status.BadRequest(
 				ø.Error(fmt.Errorf("unknown response %s", r.Path)),
@@ -119,10 +173,7 @@ func Serve(endpoints ...µ.Routable) func(events.APIGatewayProxyRequest) (events
 		case *µ.Output:
 			return output(v, req)
 		case µ.NoMatch:
-			failure := ø.Status.NotImplemented(
-				ø.Error(fmt.Errorf("NoMatch %s", r.Path)),
-			).(*µ.Output)
-			return output(failure, req)
+			return output(v.Output(), req)
 		default:
 			failure := ø.Status.InternalServerError(
 				ø.Error(fmt.Errorf("unknown response %s", r.Path)),
@@ -19,8 +19,11 @@
 package apigateway_test
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	µ "github.com/fogfish/gouldian/v2"
@@ -65,7 +68,7 @@ func TestServeNoMatch(t *testing.T) {
 	it.Ok(t).If(err1).Must().Equal(nil)
 
 	it.Ok(t).
-		If(out.StatusCode).Should().Equal(http.StatusNotImplemented).
+		If(out.StatusCode).Should().Equal(http.StatusNotFound).
 		If(out.Headers["Content-Type"]).Should().Equal("application/json").
 		If(out.Body).ShouldNot().Equal("")
 
@@ -75,6 +78,23 @@ func TestServeNoMatch(t *testing.T) {
 	// "Access-Control-Max-Age":       "600",
 }
 
+func TestServeMethodNotAllowed(t *testing.T) {
+	api := apigateway.Serve(mock("echo"))
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod: "PUT",
+		Path:       "/echo",
+	}
+
+	out, err1 := api(req)
+	it.Ok(t).If(err1).Must().Equal(nil)
+
+	it.Ok(t).
+		If(out.StatusCode).Should().Equal(http.StatusMethodNotAllowed).
+		If(out.Headers["Allow"]).Should().Equal("GET").
+		If(out.Headers["Content-Type"]).Should().Equal("application/json").
+		If(out.Body).ShouldNot().Equal("")
+}
+
 func TestServeMatchUnescaped(t *testing.T) {
 	api := apigateway.Serve(mock("h%rt"))
 	req := events.APIGatewayProxyRequest{
@@ -89,6 +109,31 @@ func TestServeMatchUnescaped(t *testing.T) {
 		If(out.StatusCode).Should().Equal(http.StatusBadRequest)
 }
 
+func TestServeHosts(t *testing.T) {
+	api := apigateway.ServeRouter(
+		µ.NewHosts(
+			µ.VHost("api.example.com", µ.NewRoutes(mock("echo"))),
+		),
+	)
+
+	for host, status := range map[string]int{
+		"api.example.com":   http.StatusOK,
+		"admin.example.com": http.StatusNotFound,
+	} {
+		req := events.APIGatewayProxyRequest{
+			HTTPMethod:     "GET",
+			Path:           "/echo",
+			RequestContext: events.APIGatewayProxyRequestContext{DomainName: host},
+		}
+
+		out, err1 := api(req)
+		it.Ok(t).If(err1).Must().Equal(nil)
+
+		it.Ok(t).
+			If(out.StatusCode).Should().Equal(status)
+	}
+}
+
 func TestServeAndCommit(t *testing.T) {
 	cnt := 0
 	api := apigateway.ServeAndCommit(
@@ -112,6 +157,32 @@ func TestServeAndCommit(t *testing.T) {
 		If(cnt).Should().Equal(1)
 }
 
+func TestServeAndCommitContext(t *testing.T) {
+	cnt := 0
+	api := apigateway.ServeAndCommitContext(
+		func() { cnt = cnt + 1 },
+		µ.GET(
+			µ.URI(µ.Path("deadline")),
+			func(ctx *µ.Context) error {
+				if _, ok := ctx.Deadline(); !ok {
+					return ø.Status.InternalServerError()
+				}
+				return ø.Status.OK()
+			},
+		),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	out, err := api(ctx, events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/deadline"})
+
+	it.Ok(t).
+		If(err).Must().Equal(nil).
+		If(out.StatusCode).Should().Equal(http.StatusOK).
+		If(cnt).Should().Equal(1)
+}
+
 func mock(path string) µ.Routable {
 	return µ.GET(
 		µ.URI(µ.Path(path)),
@@ -124,3 +195,90 @@ func mock(path string) µ.Routable {
 		},
 	)
 }
+
+func TestServeDiagnosticNoMatch(t *testing.T) {
+	type T struct{ Limit int }
+	limit := µ.Optics1[T, int]()
+
+	api := apigateway.Serve(
+		µ.GET(µ.URI(µ.Path("echo")), µ.Param("limit", limit)),
+		µ.GET(µ.URI(µ.Path("auth")), µ.JWT(µ.Token.Sub, "joe")),
+	)
+
+	for _, tt := range []struct {
+		req    events.APIGatewayProxyRequest
+		detail string
+		status int
+	}{
+		{
+			events.APIGatewayProxyRequest{
+				HTTPMethod:            "GET",
+				Path:                  "/echo",
+				QueryStringParameters: map[string]string{"limit": "abc"},
+			},
+			"param limit is not valid",
+			http.StatusBadRequest,
+		},
+		{
+			events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/auth"},
+			"jwt token is missing",
+			http.StatusUnauthorized,
+		},
+	} {
+		out, err1 := api(tt.req)
+		it.Ok(t).If(err1).Must().Equal(nil)
+
+		var issue µ.Issue
+		err2 := json.Unmarshal([]byte(out.Body), &issue)
+		it.Ok(t).If(err2).Must().Equal(nil)
+
+		it.Ok(t).
+			If(out.StatusCode).Should().Equal(tt.status).
+			If(out.Headers["Content-Type"]).Should().Equal("application/json").
+			If(issue.Detail).Should().Equal(tt.detail)
+	}
+}
+
+func TestServePanic(t *testing.T) {
+	api := apigateway.Serve(
+		µ.GET(
+			µ.URI(µ.Path("panic")),
+			func(ctx *µ.Context) error { panic("boom") },
+		),
+	)
+
+	out, err1 := api(events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/panic"})
+	it.Ok(t).If(err1).Must().Equal(nil)
+
+	var issue µ.Issue
+	err2 := json.Unmarshal([]byte(out.Body), &issue)
+	it.Ok(t).If(err2).Must().Equal(nil)
+
+	it.Ok(t).
+		If(out.StatusCode).Should().Equal(http.StatusInternalServerError).
+		If(out.Headers["Content-Type"]).Should().Equal("application/json").
+		If(issue.ID).ShouldNot().Equal("")
+}
+
+func TestServeContext(t *testing.T) {
+	type key string
+
+	api := apigateway.ServeContext(
+		µ.GET(
+			µ.URI(µ.Path("context")),
+			func(ctx *µ.Context) error {
+				if ctx.Value(key("request")) != "id" {
+					return ø.Status.InternalServerError()
+				}
+				return ø.Status.OK()
+			},
+		),
+	)
+
+	ctx := context.WithValue(context.Background(), key("request"), "id")
+	out, err := api(ctx, events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/context"})
+
+	it.Ok(t).
+		If(err).Must().Equal(nil).
+		If(out.StatusCode).Should().Equal(http.StatusOK)
+}
@@ -34,8 +34,23 @@ Serve builds http.Handler for sequence of endpoints
 	http.ListenAndServe(":8080", httpd.Server( ... ))
 */
 func Serve(endpoints ...µ.Routable) http.Handler {
+	return ServeRouter(µ.NewRoutes(endpoints...))
+}
+
+/*
+ServeRouter builds http.Handler for the routing table, interceptors are
+applied to every request, panics are recovered with 500 Internal Server Error
+
+	http.ListenAndServe(":8080",
+		httpd.ServeRouter(
+			µ.NewRoutes( ... ).With(µ.AutoOptions()),
+			accessLog,
+		),
+	)
+*/
+func ServeRouter(router µ.Router, seq ...µ.Interceptor) http.Handler {
 	routes := &routes{
-		endpoint: µ.NewRoutes(endpoints...).Endpoint(),
+		endpoint: µ.Recover(µ.InterceptRouter(router, seq...).Endpoint()),
 	}
 
 	routes.pool.New = func() interface{} {
@@ -67,7 +82,9 @@ type routes struct {
 
 func (routes *routes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	req := routes.pool.Get().(*µ.Context)
+	defer routes.pool.Put(req)
 	req.Free()
+	req.Context = r.Context()
 	req.Request = r
 
 	switch v := routes.endpoint(req).(type) {
@@ -75,18 +92,13 @@ func (routes *routes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case *µ.Output:
 		routes.output(w, r, v)
 	case µ.NoMatch:
-		failure := ø.Status.NotImplemented(
-			ø.Error(fmt.Errorf("NoMatch %s", r.URL.Path)),
-		).(*µ.Output)
-		routes.output(w, r, failure)
+		routes.output(w, r, v.Output())
 	default:
 		failure := ø.Status.InternalServerError(
 			ø.Error(fmt.Errorf("unknown response %s", r.URL.Path)),
 		).(*µ.Output)
 		routes.output(w, r, failure)
 	}
-
-	routes.pool.Put(req)
 }
 
 func (routes *routes) output(w http.ResponseWriter, r *http.Request, out *µ.Output) {
@@ -19,6 +19,7 @@
 package httpd_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -65,11 +66,75 @@ func TestServeNoMatch(t *testing.T) {
 	it.Ok(t).If(err3).Must().Equal(nil)
 
 	it.Ok(t).
-		If(out.StatusCode).Should().Equal(http.StatusNotImplemented).
+		If(out.StatusCode).Should().Equal(http.StatusNotFound).
 		If(out.Header.Get("Content-Type")).Should().Equal("application/json").
 		If(msg).ShouldNot().Equal([]byte{})
 }
 
+func TestServeMethodNotAllowed(t *testing.T) {
+	ts := httptest.NewServer(httpd.Serve(mock()))
+	defer ts.Close()
+
+	req, err1 := http.NewRequest("PUT", ts.URL+"/echo", nil)
+	it.Ok(t).If(err1).Must().Equal(nil)
+
+	out, err2 := http.DefaultClient.Do(req)
+	it.Ok(t).If(err2).Must().Equal(nil)
+
+	msg, err3 := io.ReadAll(out.Body)
+	it.Ok(t).If(err3).Must().Equal(nil)
+
+	it.Ok(t).
+		If(out.StatusCode).Should().Equal(http.StatusMethodNotAllowed).
+		If(out.Header.Get("Allow")).Should().Equal("GET").
+		If(out.Header.Get("Content-Type")).Should().Equal("application/json").
+		If(msg).ShouldNot().Equal([]byte{})
+}
+
+func TestServeRouterHead(t *testing.T) {
+	ts := httptest.NewServer(
+		httpd.ServeRouter(µ.NewRoutes(mock()).With(µ.AutoHead())),
+	)
+	defer ts.Close()
+
+	req, err1 := http.NewRequest("HEAD", ts.URL+"/echo", nil)
+	it.Ok(t).If(err1).Must().Equal(nil)
+
+	out, err2 := http.DefaultClient.Do(req)
+	it.Ok(t).If(err2).Must().Equal(nil)
+
+	msg, err3 := io.ReadAll(out.Body)
+	it.Ok(t).If(err3).Must().Equal(nil)
+
+	it.Ok(t).
+		If(out.StatusCode).Should().Equal(http.StatusOK).
+		If(out.Header.Get("Server")).Should().Equal("echo").
+		If(out.Header.Get("Content-Length")).Should().Equal("4").
+		If(msg).Should().Equal([]byte{})
+}
+
+func TestServeRouterInterceptor(t *testing.T) {
+	ts := httptest.NewServer(
+		httpd.ServeRouter(µ.NewRoutes(mock()),
+			func(ctx *µ.Context, next µ.Endpoint) error {
+				err := next(ctx)
+				if out, ok := err.(*µ.Output); ok {
+					out.SetHeader("X-Intercepted", "true")
+				}
+				return err
+			},
+		),
+	)
+	defer ts.Close()
+
+	out, err := http.Get(ts.URL + "/echo")
+	it.Ok(t).If(err).Must().Equal(nil)
+
+	it.Ok(t).
+		If(out.StatusCode).Should().Equal(http.StatusOK).
+		If(out.Header.Get("X-Intercepted")).Should().Equal("true")
+}
+
 func TestServeUnknownError(t *testing.T) {
 	ts := httptest.NewServer(
 		httpd.Serve(
@@ -133,3 +198,95 @@ func mock() µ.Routable {
 	)
 
 }
+
+func TestServeDiagnosticNoMatch(t *testing.T) {
+	type T struct{ Limit int }
+	limit := µ.Optics1[T, int]()
+
+	ts := httptest.NewServer(
+		httpd.Serve(
+			µ.GET(µ.URI(µ.Path("echo")), µ.Param("limit", limit)),
+			µ.PUT(µ.URI(µ.Path("echo")), µ.ContentType.JSON),
+		),
+	)
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		method, url, detail string
+		status              int
+	}{
+		{"GET", "/echo?limit=abc", "param limit is not valid", http.StatusBadRequest},
+		{"PUT", "/echo", "header Content-Type is missing", http.StatusUnsupportedMediaType},
+	} {
+		req, err1 := http.NewRequest(tt.method, ts.URL+tt.url, nil)
+		it.Ok(t).If(err1).Must().Equal(nil)
+
+		out, err2 := http.DefaultClient.Do(req)
+		it.Ok(t).If(err2).Must().Equal(nil)
+
+		var issue µ.Issue
+		err3 := json.NewDecoder(out.Body).Decode(&issue)
+		it.Ok(t).If(err3).Must().Equal(nil)
+
+		it.Ok(t).
+			If(out.StatusCode).Should().Equal(tt.status).
+			If(out.Header.Get("Content-Type")).Should().Equal("application/json").
+			If(issue.Status).Should().Equal(tt.status).
+			If(issue.Detail).Should().Equal(tt.detail)
+	}
+}
+
+func TestServePanic(t *testing.T) {
+	ts := httptest.NewServer(
+		httpd.Serve(
+			µ.GET(
+				µ.URI(µ.Path("panic")),
+				func(ctx *µ.Context) error { panic("boom") },
+			),
+		),
+	)
+	defer ts.Close()
+
+	for i := 0; i < 2; i++ {
+		req, err1 := http.NewRequest("GET", ts.URL+"/panic", nil)
+		it.Ok(t).If(err1).Must().Equal(nil)
+
+		out, err2 := http.DefaultClient.Do(req)
+		it.Ok(t).If(err2).Must().Equal(nil)
+
+		var issue µ.Issue
+		err3 := json.NewDecoder(out.Body).Decode(&issue)
+		it.Ok(t).If(err3).Must().Equal(nil)
+
+		it.Ok(t).
+			If(out.StatusCode).Should().Equal(http.StatusInternalServerError).
+			If(issue.Status).Should().Equal(http.StatusInternalServerError).
+			If(issue.ID).ShouldNot().Equal("")
+	}
+}
+
+func TestServeRequestContext(t *testing.T) {
+	ts := httptest.NewServer(
+		httpd.Serve(
+			µ.GET(
+				µ.URI(µ.Path("context")),
+				func(ctx *µ.Context) error {
+					if ctx.Context != ctx.Request.Context() {
+						return ø.Status.InternalServerError()
+					}
+					return ø.Status.OK()
+				},
+			),
+		),
+	)
+	defer ts.Close()
+
+	req, err1 := http.NewRequest("GET", ts.URL+"/context", nil)
+	it.Ok(t).If(err1).Must().Equal(nil)
+
+	out, err2 := http.DefaultClient.Do(req)
+	it.Ok(t).If(err2).Must().Equal(nil)
+
+	it.Ok(t).
+		If(out.StatusCode).Should().Equal(http.StatusOK)
+}
@@ -0,0 +1,94 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+/*
+Timeout limits the execution of the endpoint by the time budget. The context
+of request is replaced with one that is cancelled when budget is exceeded,
+the endpoint observes it (e.g. ctx.Done()) and aborts the execution.
+
+	µ.GET(
+		µ.URI(µ.Path("users")),
+		µ.Timeout(5*time.Second,
+			µ.FMap(func(ctx *µ.Context, req *MyT) error { ... }),
+		),
+	)
+
+The timeout is cooperative, the endpoint is not preempted. The endpoint
+that ignores ctx.Context is not interrupted, it runs until completion.
+The failure of the endpoint is replaced with RFC 7807 Issue if the context
+is done by the time the endpoint returns: 504 Gateway Timeout if the deadline
+is exceeded, 503 Service Unavailable if the request has been cancelled
+(e.g. client is disconnected). The success (nil or 2xx Output) is returned
+as-is, the endpoint might commit its work just after the deadline.
+NoMatch is returned as-is.
+*/
+func Timeout(d time.Duration, endpoint Endpoint) Endpoint {
+	return func(ctx *Context) error {
+		parent := ctx.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+
+		deadline, cancel := context.WithTimeout(parent, d)
+		ctx.Context = deadline
+		defer func() {
+			cancel()
+			ctx.Context = parent
+		}()
+
+		err := endpoint(ctx)
+		if deadline.Err() == nil || isSuccess(err) {
+			return err
+		}
+
+		switch v := err.(type) {
+		case NoMatch:
+			return err
+		case *Output:
+			v.Free()
+		}
+
+		status := http.StatusGatewayTimeout
+		if deadline.Err() == context.Canceled {
+			status = http.StatusServiceUnavailable
+		}
+
+		out := NewOutput(status)
+		out.SetIssue(fmt.Errorf("timeout %v: %w", d, deadline.Err()))
+		return out
+	}
+}
+
+// isSuccess checks if the endpoint has completed successfully
+func isSuccess(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	out, ok := err.(*Output)
+	return ok && out.Status >= 200 && out.Status < 300
+}
@@ -0,0 +1,111 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	ø "github.com/fogfish/gouldian/v2/output"
+	"github.com/fogfish/it/v2"
+)
+
+func TestTimeout(t *testing.T) {
+	slow := func(ctx *µ.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return ø.Status.OK()
+		}
+	}
+
+	t.Run("Exceeded", func(t *testing.T) {
+		foo := µ.Timeout(10*time.Millisecond, slow)
+		req := mock.Input()
+		err := foo(req)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusGatewayTimeout)),
+			it.Equal(err.(*µ.Output).GetHeader("Content-Type"), "application/json"),
+			it.Nil(req.Err()),
+		)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		foo := µ.Timeout(time.Second, slow)
+		req := mock.Input()
+		req.Context = parent
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(foo(req), http.StatusServiceUnavailable)),
+		)
+	})
+
+	t.Run("Within", func(t *testing.T) {
+		foo := µ.Timeout(time.Second, func(ctx *µ.Context) error {
+			_, ok := ctx.Deadline()
+			if !ok {
+				return ø.Status.InternalServerError()
+			}
+			return ø.Status.OK()
+		})
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(foo(mock.Input()), http.StatusOK)),
+		)
+	})
+
+	t.Run("CompletedAfterDeadline", func(t *testing.T) {
+		foo := µ.Timeout(10*time.Millisecond, func(ctx *µ.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			return ø.Status.Created()
+		})
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(foo(mock.Input()), http.StatusCreated)),
+		)
+	})
+
+	t.Run("FailedAfterDeadline", func(t *testing.T) {
+		foo := µ.Timeout(10*time.Millisecond, func(ctx *µ.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			return ø.Status.InternalServerError()
+		})
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(foo(mock.Input()), http.StatusGatewayTimeout)),
+		)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		foo := µ.Timeout(0, func(ctx *µ.Context) error { return µ.ErrNoMatch })
+
+		it.Then(t).Should(
+			it.Equal(foo(mock.Input()), µ.ErrNoMatch),
+		)
+	})
+}
@@ -2,6 +2,10 @@ package gouldian
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -11,24 +15,31 @@ Node of trie
 */
 type Node struct {
 	Path string   // substring from the route "owned" by the node
-	Heir []*Node  // heir nodes
+	Heir []*Node  // heir nodes, literals are followed by wildcards
 	Func Endpoint // end point associated with node
-	/*
-		TODO
-		- Wild     *Node    // special node that captures any path
-		- Type     int      // Node type
-	*/
+	Spec []Spec   // routing metadata of endpoints associated with node
+	kind byte     // type of node, either literal or wildcard symbol
+	name string   // name of wildcard constraint, empty if any value matches
+	pred string   // identity of wildcard constraint predicate
+	test matcher  // test of wildcard constraint, it captures segment value(s)
+	opts options  // routing table options, defined at root node only
 }
 
+// matcher tests the segment value, captures value(s) if succeeded
+type matcher func(string, *[]string) bool
+
+// Types of trie nodes
+const (
+	nodeLiteral = byte(0)
+	nodeLens    = byte(':') // wildcard that captures the segment
+	nodeAny     = byte('_') // wildcard that matches any segment
+	nodeAll     = byte('*') // wildcard that captures remaining path
+)
+
 // NewRoutes creates new routing table
 func NewRoutes(seq ...Routable) *Node {
 	root := &Node{
-		Heir: []*Node{
-			{
-				Path: "/",
-				Heir: make([]*Node, 0),
-			},
-		},
+		Heir: make([]*Node, 0),
 	}
 
 	for _, route := range seq {
@@ -40,179 +51,588 @@ func NewRoutes(seq ...Routable) *Node {
 
 /*
 
-lookup is hot-path discovery of node at the path
+Option of routing table
 */
-func (root *Node) lookup(path string, values *[]string) (at int, node *Node) {
-	node = root
-lookup:
-	for {
-		// leaf node, no futher lookup is possible
-		// return current `node`` and position `at` path
-		if len(node.Heir) == 0 {
-			return
-		}
+type Option func(*options)
+
+type options struct {
+	autoOptions     bool
+	autoHead        bool
+	trailingSlash   SlashPolicy
+	collapseSlashes bool
+	caseInsensitive bool
+	rawPath         bool
+}
 
-		for _, heir := range node.Heir {
-			if len(path[at:]) < len(heir.Path) {
-				// No match, path cannot match node
-				continue
+/*
+
+AutoOptions enables synthesis of responses to OPTIONS requests. The response
+lists HTTP verbs of endpoints registered at the path. Explicitly declared
+OPTIONS endpoints have priority over synthesized one.
+*/
+func AutoOptions() Option {
+	return func(opts *options) { opts.autoOptions = true }
+}
+
+/*
+
+AutoHead enables serving of HEAD requests by the matching GET endpoint.
+The body of response is stripped, Content-Length is kept. Explicitly declared
+HEAD endpoints have priority over synthesized one.
+*/
+func AutoHead() Option {
+	return func(opts *options) { opts.autoHead = true }
+}
+
+/*
+
+SlashPolicy defines handling of trailing slash by the routing table
+*/
+type SlashPolicy int
+
+// Trailing slash policies
+const (
+	// SlashStrict distinguishes paths `/users` and `/users/`
+	SlashStrict SlashPolicy = iota
+	// SlashIgnore routes the path with or without trailing slash
+	// to the registered one
+	SlashIgnore
+	// SlashRedirect301 redirects to the registered path with 301 Moved Permanently
+	SlashRedirect301
+	// SlashRedirect308 redirects to the registered path with 308 Permanent Redirect
+	SlashRedirect308
+)
+
+func (policy SlashPolicy) String() string {
+	switch policy {
+	case SlashIgnore:
+		return "ignore"
+	case SlashRedirect301:
+		return "redirect 301"
+	case SlashRedirect308:
+		return "redirect 308"
+	default:
+		return "strict"
+	}
+}
+
+/*
+
+TrailingSlash defines the policy of trailing slash handling. The redirect
+policies redirect to the canonical path any request that is routed after
+normalization, including collapse of duplicate slashes.
+*/
+func TrailingSlash(policy SlashPolicy) Option {
+	return func(opts *options) { opts.trailingSlash = policy }
+}
+
+/*
+
+CollapseSlashes enables routing of paths with duplicate slashes,
+`//users` is routed as `/users`.
+*/
+func CollapseSlashes() Option {
+	return func(opts *options) { opts.collapseSlashes = true }
+}
+
+/*
+
+CaseInsensitive enables case-insensitive matching of literal segments.
+Values captured by lenses are preserved as-is.
+*/
+func CaseInsensitive() Option {
+	return func(opts *options) { opts.caseInsensitive = true }
+}
+
+/*
+
+RawPath enables routing on the escaped path of request. The path is split on
+raw slashes, each segment is decoded before it is lifted to the context.
+Percent-encoded slash `%2F` becomes a part of segment value, e.g. S3 object
+key `a%2Fb.txt` is captured by a single lens. Literals are matched as
+decoded values, e.g. `h%rt` matches `/h%25rt`.
+*/
+func RawPath() Option {
+	return func(opts *options) { opts.rawPath = true }
+}
+
+/*
+
+With applies options to the routing table
+
+	µ.NewRoutes( ... ).With(µ.AutoOptions(), µ.AutoHead())
+*/
+func (root *Node) With(opts ...Option) *Node {
+	for _, opt := range opts {
+		opt(&root.opts)
+	}
+	return root
+}
+
+/*
+
+lookup is hot-path discovery of node at the path. The lookup prefers
+literal segments, it backtracks to wildcard siblings if deeper match fails
+or the node does not accept the verb. Empty verb matches any node.
+It returns nil if none of endpoints matches the path.
+*/
+func (root *Node) lookup(path, verb string, values *[]string) *Node {
+	return root.match(path, 0, verb, values, root.opts.caseInsensitive, root.opts.rawPath)
+}
+
+/*
+
+resolve discovers node at one of the paths that accepts the verb. Every path
+is tried with the verb before it falls back to any node at the paths,
+the verb is reported as not allowed by this node. It returns the path
+of discovered node.
+*/
+func (root *Node) resolve(paths []string, verb string, values *[]string) (*Node, string) {
+	// empty verb matches any node, it is the last resort
+	verbs := [3]string{verb}
+	n := 2
+	if verb == http.MethodHead && root.opts.autoHead {
+		verbs[1], n = http.MethodGet, 3
+	}
+
+	for _, v := range verbs[:n] {
+		for _, path := range paths {
+			if node := root.lookup(path, v, values); node != nil {
+				return node, path
 			}
+		}
+	}
 
-			if path[at] != heir.Path[0] {
-				// No match, path cannot match node
-				// this is micro-optimization to reduce overhead of memequal
-				continue
+	return nil, paths[0]
+}
+
+func (root *Node) match(path string, at int, verb string, values *[]string, fold, raw bool) *Node {
+	// entire path is consumed, the node matches only if it has endpoint
+	if at == len(path) {
+		if root.Func != nil && (verb == "" || root.accepts(verb)) {
+			return root
+		}
+		return nil
+	}
+
+	n := len(*values)
+	for _, heir := range root.Heir {
+		if len(path[at:]) < len(heir.Path) {
+			// No match, path cannot match node
+			continue
+		}
+
+		if path[at] != heir.Path[0] && !(fold && lower(path[at]) == lower(heir.Path[0])) {
+			// No match, path cannot match node
+			// this is micro-optimization to reduce overhead of memequal
+			continue
+		}
+
+		switch heir.kind {
+		case nodeLiteral:
+			p := len(heir.Path)
+			if !isLiteral(path[at:at+p], heir.Path, fold) {
+				if p = literalLength(path[at:], heir.Path, fold, raw); p == -1 {
+					continue
+				}
 			}
 
+			if node := heir.match(path, at+p, verb, values, fold, raw); node != nil {
+				return node
+			}
+		case nodeAll:
 			// the node consumers entire path
-			if len(heir.Path) == 2 && heir.Path[1] == '*' {
+			if heir.Func != nil && (verb == "" || heir.accepts(verb)) {
 				*values = append(*values, path[at+1:])
-				at = len(path)
-				node = heir
-				return
+				return heir
 			}
+		default:
+			// the node is a wild-card that matches any path segment
+			// let's skip the path until next segment and re-call the value
+			p := segmentLength(path, at)
 
-			if len(heir.Path) == 2 && (heir.Path[1] == ':' || heir.Path[1] == '_' || heir.Path[1] == '*') {
-				// the node is a wild-card that matches any path segment
-				// let's skip the path until next segment and re-call the value
-				p := 1
-				max := len(path[at:])
-				for p < max && path[at+p] != '/' {
-					p++
+			switch {
+			case heir.test != nil:
+				if !heir.test(path[at+1:at+p], values) {
+					continue
 				}
+			case heir.kind == nodeLens:
+				*values = append(*values, path[at+1:at+p])
+			}
 
-				if heir.Path[1] == ':' {
-					*values = append(*values, path[at+1:at+p])
-				}
+			if node := heir.match(path, at+p, verb, values, fold, raw); node != nil {
+				return node
+			}
+
+			// backtrack values captured by the branch
+			*values = (*values)[:n]
+		}
+	}
+
+	return nil
+}
 
-				at = at + p
-				node = heir
-				continue lookup
+/*
+
+nodesAt collects every node with endpoint that matches the path regardless
+of verb, the order of nodes is same as lookup prefers them.
+*/
+func (root *Node) nodesAt(path string, at int, fold, raw bool, seq []*Node) []*Node {
+	if at == len(path) {
+		if root.Func != nil {
+			seq = append(seq, root)
+		}
+		return seq
+	}
+
+	values := make([]string, 0, 2)
+	for _, heir := range root.Heir {
+		if len(path[at:]) < len(heir.Path) || (heir.kind != nodeLiteral && path[at] != '/') {
+			continue
+		}
+
+		switch heir.kind {
+		case nodeLiteral:
+			if p := literalLength(path[at:], heir.Path, fold, raw); p != -1 {
+				seq = heir.nodesAt(path, at+p, fold, raw, seq)
+			}
+		case nodeAll:
+			if heir.Func != nil {
+				seq = append(seq, heir)
 			}
+		default:
+			p := segmentLength(path, at)
+			if heir.test == nil || heir.test(path[at+1:at+p], &values) {
+				seq = heir.nodesAt(path, at+p, fold, raw, seq)
+			}
+			values = values[:0]
+		}
+	}
+
+	return seq
+}
 
-			if path[at:at+len(heir.Path)] == heir.Path {
-				// node matches the path, continue lookup
-				at = at + len(heir.Path)
-				node = heir
-				continue lookup
+// isLiteral compares path with literal, optionally ignoring case
+func isLiteral(path, literal string, fold bool) bool {
+	return path == literal || (fold && strings.EqualFold(path, literal))
+}
+
+// literalLength returns length of path prefix matched by the literal, -1 if
+// the literal is not matched. Percent sign of literal is escaped at raw path.
+func literalLength(path, literal string, fold, raw bool) int {
+	if len(path) >= len(literal) && isLiteral(path[:len(literal)], literal, fold) {
+		return len(literal)
+	}
+
+	if !raw || strings.IndexByte(literal, '%') == -1 {
+		return -1
+	}
+
+	at := 0
+	for i := 0; i < len(literal); i++ {
+		switch c := literal[i]; {
+		case c == '%':
+			if !strings.HasPrefix(path[at:], "%25") {
+				return -1
 			}
+			at += 3
+		case at < len(path) && (path[at] == c || (fold && lower(path[at]) == lower(c))):
+			at++
+		default:
+			return -1
 		}
+	}
+	return at
+}
 
-		return
+func lower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
 	}
+	return c
+}
+
+// segmentLength returns length of the path segment started at the position
+func segmentLength(path string, at int) int {
+	p := 1
+	max := len(path[at:])
+	for p < max && path[at+p] != '/' {
+		p++
+	}
+	return p
+}
+
+/*
+
+violation explains why the path is not matched. It looks up the path ignoring
+constraints of wildcard nodes, and reports the first segment on the matching
+branch that violates the constraint.
+*/
+func (root *Node) violation(path string, at int, fold, raw bool, segment, constraint *string) bool {
+	if at == len(path) {
+		return root.Func != nil
+	}
+
+	for _, heir := range root.Heir {
+		if len(path[at:]) < len(heir.Path) || (heir.kind != nodeLiteral && path[at] != '/') {
+			continue
+		}
+
+		switch heir.kind {
+		case nodeLiteral:
+			if p := literalLength(path[at:], heir.Path, fold, raw); p != -1 && heir.violation(path, at+p, fold, raw, segment, constraint) {
+				return true
+			}
+		case nodeAll:
+			if heir.Func != nil {
+				return true
+			}
+		default:
+			p := segmentLength(path, at)
+			if heir.violation(path, at+p, fold, raw, segment, constraint) {
+				values := make([]string, 0, 2)
+				if heir.test != nil && !heir.test(path[at+1:at+p], &values) {
+					*segment, *constraint = path[at+1:at+p], heir.name
+				}
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 /*
 
 appendEndpoint to trie under the path.
 Input path is a collection of segments, each segment is either path literal or
-wildcard symbol `:` reserved for lenses
+wildcard, optionally constrained.
 */
-func (root *Node) appendEndpoint(path []string, endpoint Endpoint) {
-	if len(path) == 0 {
-		_, n := root.appendTo("/")
-
-		if n.Func == nil {
-			n.Func = endpoint
-		} else {
-			n.Func = n.Func.Or(endpoint)
+func (root *Node) appendEndpoint(spec Spec) {
+	if spec.Group != nil {
+		for _, heir := range spec.Group {
+			root.appendEndpoint(heir)
 		}
 		return
 	}
 
-	at := 0
 	node := root
-	for i, segment := range path {
-		// `/` required to speed up lookup on the hot-path
-		segment = "/" + segment
-		at, node = node.appendTo(segment)
-
-		// split the node and add endpoint
-		if len(segment[at:]) != 0 {
-			split := &Node{
-				Path: segment[at:],
-				Heir: make([]*Node, 0),
-			}
-			node.Heir = append(node.Heir, split)
-			node = split
-		}
+	if len(spec.Segments) == 0 {
+		node = node.appendLiteral("/")
+	}
 
-		// the last segment needs to be enhanced with endpoint
-		if i == len(path)-1 {
-			if node.Func == nil {
-				node.Func = endpoint
-			} else {
-				node.Func = node.Func.Or(endpoint)
-			}
+	for i, segment := range spec.Segments {
+		switch kind := segment.kind(i == len(spec.Segments)-1); kind {
+		case nodeLiteral:
+			// `/` required to speed up lookup on the hot-path
+			node = node.appendLiteral("/" + segment.path)
+		default:
+			node = node.appendWildcard(kind, segment.name(), segment.identity(), segment.test())
 		}
 	}
+
+	node.appendSpec(spec)
 }
 
 /*
 
-appendTo finds the node in trie where to add path (or segment).
-It returns the candidate node and length of "consumed" path
+appendLiteral finds or creates the node in trie that owns the path.
+Literal nodes shares common prefixes, the node is split if needed.
 */
-func (root *Node) appendTo(path string) (at int, node *Node) {
-	node = root
+func (root *Node) appendLiteral(path string) *Node {
+	at := 0
+	node := root
+
 lookup:
-	for {
-		if len(node.Heir) == 0 {
-			// leaf node, no futher lookup is possible
-			// return current `node`` and position `at` path
-			return
-		}
+	for at < len(path) {
+		for i, heir := range node.Heir {
+			if heir.kind != nodeLiteral {
+				continue
+			}
 
-		for _, heir := range node.Heir {
 			prefix := longestCommonPrefix(path[at:], heir.Path)
-			at = at + prefix
-			switch {
-			case prefix == 0:
+			if prefix == 0 {
 				// No common prefix, jump to next heir
 				continue
-			case prefix == len(heir.Path):
-				// Common prefix is the node itself, continue lookup into heirs
-				node = heir
-				continue lookup
-			default:
+			}
+
+			if prefix < len(heir.Path) {
 				// Common prefix is shorter than node itself, split is required
-				if prefixNode := node.heirByPath(heir.Path[:prefix]); prefixNode != nil {
-					// prefix already exists, current node needs to be moved
-					// under existing one
-					node.Path = node.Path[prefix:]
-					prefixNode.Heir = append(prefixNode.Heir, node)
-					node = prefixNode
-					return
+				split := &Node{
+					Path: heir.Path[:prefix],
+					Heir: []*Node{heir},
 				}
+				heir.Path = heir.Path[prefix:]
+				node.Heir[i] = split
+				heir = split
+			}
 
-				// prefix does not exist, current node needs to be split
-				// the list of heirs needs to be patched
-				for j := 0; j < len(node.Heir); j++ {
-					if node.Heir[j].Path == heir.Path {
-						n := heir
-						node.Heir[j] = &Node{
-							Path: heir.Path[:prefix],
-							Heir: []*Node{n},
-						}
-						n.Path = heir.Path[prefix:]
-						node = node.Heir[j]
-						return
-					}
-				}
+			// Common prefix is the node itself, continue lookup into heirs
+			at = at + prefix
+			node = heir
+			continue lookup
+		}
+
+		// No heir is found, the remaining path becomes new node
+		heir := &Node{
+			Path: path[at:],
+			Heir: make([]*Node, 0),
+		}
+		node.appendHeir(heir)
+		return heir
+	}
+
+	return node
+}
+
+/*
+
+appendWildcard finds or creates wildcard node, wildcards of same kind
+share the node if their constraints are equal. It panics if constraints
+of same name have different predicates.
+*/
+func (root *Node) appendWildcard(kind byte, name, pred string, test matcher) *Node {
+	for _, heir := range root.Heir {
+		if heir.kind == kind && heir.name == name {
+			if heir.pred != pred {
+				panic("constraint " + name + " is defined with different predicates")
 			}
+			return heir
 		}
-		// No heir is found return current node
-		return
 	}
+
+	heir := &Node{
+		Path: "/" + string(kind),
+		Heir: make([]*Node, 0),
+		kind: kind,
+		name: name,
+		pred: pred,
+		test: test,
+	}
+	root.appendHeir(heir)
+	return heir
 }
 
-func (root *Node) heirByPath(path string) *Node {
-	for i := 0; i < len(root.Heir); i++ {
-		if root.Heir[i].Path == path {
-			return root.Heir[i]
+/*
+
+appendHeir keeps heirs ordered by priority: literals, constrained wildcards,
+lenses, any and all.
+*/
+func (root *Node) appendHeir(heir *Node) {
+	at := len(root.Heir)
+	for at > 0 && heir.rank() < root.Heir[at-1].rank() {
+		at--
+	}
+
+	root.Heir = append(root.Heir, nil)
+	copy(root.Heir[at+1:], root.Heir[at:])
+	root.Heir[at] = heir
+}
+
+func (root *Node) rank() int {
+	switch {
+	case root.kind == nodeLiteral:
+		return 0
+	case root.name != "":
+		return 1
+	case root.kind == nodeLens:
+		return 2
+	case root.kind == nodeAny:
+		return 3
+	default:
+		return 4
+	}
+}
+
+/*
+
+appendSpec associates endpoint with the node
+*/
+func (root *Node) appendSpec(spec Spec) {
+	if root.Func == nil {
+		root.Func = spec.Func
+	} else {
+		root.Func = root.Func.Or(spec.Func)
+	}
+	root.Spec = append(root.Spec, spec)
+}
+
+/*
+
+allow returns HTTP verbs accepted by nodes at the path, including verbs
+synthesized by the routing table. The list is empty if any verb is accepted
+or verb of some endpoint is not known.
+*/
+func (root *Node) allow(path string) []string {
+	opts := root.opts
+	nodes := root.nodesAt(path, 0, opts.caseInsensitive, opts.rawPath, nil)
+
+	seq := make([]string, 0, len(nodes)+2)
+	for _, node := range nodes {
+		for _, spec := range node.Spec {
+			if spec.Method == "" || spec.Method == Any {
+				return nil
+			}
+
+			if !contains(seq, spec.Method) {
+				seq = append(seq, spec.Method)
+			}
 		}
 	}
-	return nil
+
+	if opts.autoHead && contains(seq, http.MethodGet) && !contains(seq, http.MethodHead) {
+		seq = append(seq, http.MethodHead)
+	}
+
+	if opts.autoOptions && !contains(seq, http.MethodOptions) {
+		seq = append(seq, http.MethodOptions)
+	}
+
+	sort.Strings(seq)
+
+	return seq
+}
+
+/*
+
+accepts checks if some endpoint associated with the node might accept the verb.
+*/
+func (root *Node) accepts(verb string) bool {
+	for _, spec := range root.Spec {
+		if spec.Method == "" || spec.Method == Any || spec.Method == verb {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+
+optionsOf synthesizes response to OPTIONS request
+*/
+func optionsOf(allow []string) error {
+	out := NewOutput(http.StatusNoContent)
+	out.SetHeader("Allow", strings.Join(allow, ", "))
+	return out
+}
+
+/*
+
+head serves HEAD request using GET endpoint, the body of response is stripped
+*/
+func (root *Node) head(ctx *Context) error {
+	ctx.Request.Method = http.MethodGet
+	err := root.Func(ctx)
+	ctx.Request.Method = http.MethodHead
+
+	if out, ok := err.(*Output); ok {
+		if out.GetHeader("Content-Length") == "" && out.GetHeader("Transfer-Encoding") != "chunked" {
+			out.SetHeader("Content-Length", strconv.Itoa(len(out.Body)))
+		}
+		out.Body = ""
+	}
+
+	return err
 }
 
 /*
@@ -230,13 +650,11 @@ func walk(node *Node, level int, f func(int, *Node)) {
 	}
 }
 
-// Println outputs trie to console
+// Println outputs routes to console, see Routes for structured records
 func (root *Node) Println() {
-	root.Walk(
-		func(i int, n *Node) {
-			fmt.Println(strings.Repeat(" ", i), n.Path)
-		},
-	)
+	for _, route := range root.Routes() {
+		fmt.Println(verbOf(route.Method), route.Path)
+	}
 }
 
 // Endpoint converts trie to Endpoint
@@ -246,29 +664,196 @@ func (root *Node) Endpoint() Endpoint {
 			return ErrNoMatch
 		}
 
-		path := ctx.Request.URL.Path
 		ctx.free()
+		return root.route(ctx)
+	}
+}
+
+// route the request to the endpoint, values lifted to context are preserved
+func (root *Node) route(ctx *Context) (err error) {
+	path, raw := ctx.Request.URL.Path, false
+	if root.opts.rawPath && (ctx.Request.URL.RawPath != "" || strings.IndexByte(path, '%') != -1) {
+		path, raw = unescapeSegments(ctx.Request.URL.EscapedPath()), true
+	}
+
+	canonical := path
+	if root.opts.collapseSlashes {
+		path = collapseSlashes(path)
+	}
 
-		ctx.values = ctx.values[:0]
-		i, node := root.lookup(path, &ctx.values)
+	paths := []string{path}
+	if root.opts.trailingSlash != SlashStrict {
+		if alt := toggleTrailingSlash(path); alt != "" {
+			paths = append(paths, alt)
+		}
+	}
+
+	var node *Node
+	ctx.values = ctx.values[:0]
+	if node, path = root.resolve(paths, ctx.Request.Method, &ctx.values); node == nil {
+		return root.notFound(path)
+	}
 
-		if len(path) == i && node.Func != nil {
-			return node.Func(ctx)
+	if path != canonical {
+		switch root.opts.trailingSlash {
+		case SlashRedirect301:
+			return redirect(ctx, http.StatusMovedPermanently, path, raw)
+		case SlashRedirect308:
+			return redirect(ctx, http.StatusPermanentRedirect, path, raw)
 		}
+	}
 
-		return ErrNoMatch
+	if raw {
+		for i, value := range ctx.values {
+			if strings.IndexByte(value, '%') != -1 {
+				if ctx.values[i], err = url.PathUnescape(value); err != nil {
+					return NoMatch{
+						Status: http.StatusBadRequest,
+						Source: "path",
+						Key:    value,
+						Reason: reasonInvalid,
+					}
+				}
+			}
+		}
+	}
+
+	switch verb := ctx.Request.Method; {
+	case verb == http.MethodOptions && root.opts.autoOptions && !node.accepts(verb):
+		return optionsOf(root.allow(path))
+	case verb == http.MethodHead && root.opts.autoHead && !node.accepts(verb) && node.accepts(http.MethodGet):
+		return node.head(ctx)
+	}
+
+	err = node.Func(ctx)
+	if _, ok := err.(NoMatch); ok {
+		allow := root.allow(path)
+		if len(allow) != 0 && !contains(allow, ctx.Request.Method) {
+			return methodNotAllowed(ctx, allow)
+		}
 	}
+
+	return err
+}
+
+// notFound reports the path that is not matched by any route, the constraint
+// violated by path segment is reported if it is the reason
+func (root *Node) notFound(path string) error {
+	var segment, constraint string
+	if !root.violation(path, 0, root.opts.caseInsensitive, root.opts.rawPath, &segment, &constraint) || constraint == "" {
+		return ErrNotFound
+	}
+
+	out := NewOutput(http.StatusNotFound)
+	out.SetIssue(
+		fmt.Errorf("path %s is not matched", path),
+		fmt.Sprintf("path segment %q violates constraint %s", segment, constraint),
+	)
+	return out
+}
+
+// redirect builds HTTP response that redirects to canonical path
+func redirect(ctx *Context, status int, path string, raw bool) *Output {
+	location := url.URL{Path: path, RawQuery: ctx.Request.URL.RawQuery}
+	if raw {
+		seq := strings.Split(path, "/")
+		for i, segment := range seq {
+			if segment, err := url.PathUnescape(segment); err == nil {
+				seq[i] = url.PathEscape(segment)
+			}
+		}
+		location.RawPath = strings.Join(seq, "/")
+		location.Path, _ = url.PathUnescape(location.RawPath)
+	}
+
+	out := NewOutput(status)
+	out.SetHeader("Location", location.String())
+	return out
+}
+
+// unescapeSegments decodes escaped path except slash and percent sign,
+// the path is split into segments as-is, segments are decoded later.
+func unescapeSegments(path string) string {
+	buf := make([]byte, 0, len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '%' && i+2 < len(path) {
+			c, ok := unhex(path[i+1], path[i+2])
+			if ok && c != '/' && c != '%' {
+				buf = append(buf, c)
+				i += 2
+				continue
+			}
+		}
+		buf = append(buf, path[i])
+	}
+	return string(buf)
+}
+
+func unhex(a, b byte) (byte, bool) {
+	x, okx := fromhex(a)
+	y, oky := fromhex(b)
+	return x<<4 | y, okx && oky
+}
+
+func fromhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// collapseSlashes replaces sequence of slashes with single one
+func collapseSlashes(path string) string {
+	if !strings.Contains(path, "//") {
+		return path
+	}
+
+	buf := make([]byte, 0, len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		buf = append(buf, path[i])
+	}
+	return string(buf)
+}
+
+// toggleTrailingSlash returns alternative path, empty for root
+func toggleTrailingSlash(path string) string {
+	switch {
+	case len(path) <= 1:
+		return ""
+	case path[len(path)-1] == '/':
+		return path[:len(path)-1]
+	default:
+		return path + "/"
+	}
+}
+
+// methodNotAllowed builds HTTP 405 response with list of allowed methods
+func methodNotAllowed(ctx *Context, allow []string) *Output {
+	out := NewOutput(http.StatusMethodNotAllowed)
+	out.SetHeader("Allow", strings.Join(allow, ", "))
+	out.SetIssue(fmt.Errorf("method %s is not allowed at %s", ctx.Request.Method, ctx.Request.URL.Path))
+	return out
 }
 
 //
 // Utils
 //
 
-func min(a, b int) int {
-	if a <= b {
-		return a
+func contains(seq []string, x string) bool {
+	for _, v := range seq {
+		if v == x {
+			return true
+		}
 	}
-	return b
+	return false
 }
 
 func longestCommonPrefix(a, b string) (prefix int) {
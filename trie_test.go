@@ -0,0 +1,658 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+func TestRoutesNotFound(t *testing.T) {
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("foo"))),
+	).Endpoint()
+
+	for _, url := range []string{"/", "/bar", "/foo/bar"} {
+		req := mock.Input(mock.URL(url))
+		it.Then(t).Should(
+			it.Equal(foo(req), µ.ErrNotFound),
+		)
+	}
+}
+
+func TestRoutesMethodNotAllowed(t *testing.T) {
+	foo := µ.NewRoutes(
+		µ.PUT(µ.URI(µ.Path("foo"))),
+		µ.GET(µ.URI(µ.Path("foo"))),
+		µ.DELETE(µ.URI(µ.Path("foo"))),
+		µ.GET(µ.URI(µ.Path("bar"))),
+	).Endpoint()
+
+	req := mock.Input(mock.Method("POST"), mock.URL("/foo"))
+	err := foo(req)
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(err, http.StatusMethodNotAllowed)),
+		it.Equal(err.(*µ.Output).GetHeader("Allow"), "DELETE, GET, PUT"),
+	)
+}
+
+func TestRoutesMethodWildcardSibling(t *testing.T) {
+	type T struct{ ID string }
+	id := µ.Optics1[T, string]()
+
+	routes := []µ.Routable{
+		µ.DELETE(µ.URI(µ.Path("users"), µ.Path("me")), mock.Output(http.StatusOK, "me")),
+		µ.GET(µ.URI(µ.Path("users"), µ.Path(id)), mock.Output(http.StatusOK, "id")),
+	}
+	foo := µ.NewRoutes(routes...).Endpoint()
+
+	t.Run("Wildcard", func(t *testing.T) {
+		req := mock.Input(mock.URL("/users/me"))
+		var v T
+		it.Then(t).Should(
+			it.Nil(mock.CheckOutput(foo(req), "id")),
+			it.Nil(µ.FromContext(req, &v)),
+			it.Equal(v.ID, "me"),
+		)
+	})
+
+	t.Run("Literal", func(t *testing.T) {
+		req := mock.Input(mock.Method("DELETE"), mock.URL("/users/me"))
+		it.Then(t).Should(
+			it.Nil(mock.CheckOutput(foo(req), "me")),
+		)
+	})
+
+	t.Run("NotAllowed", func(t *testing.T) {
+		req := mock.Input(mock.Method("PUT"), mock.URL("/users/me"))
+		err := foo(req)
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusMethodNotAllowed)),
+			it.Equal(err.(*µ.Output).GetHeader("Allow"), "DELETE, GET"),
+		)
+	})
+
+	t.Run("AutoOptions", func(t *testing.T) {
+		bar := µ.NewRoutes(routes...).With(µ.AutoOptions()).Endpoint()
+		req := mock.Input(mock.Method("OPTIONS"), mock.URL("/users/me"))
+		err := bar(req)
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusNoContent)),
+			it.Equal(err.(*µ.Output).GetHeader("Allow"), "DELETE, GET, OPTIONS"),
+		)
+	})
+}
+
+// failure of µ.Header("X-Foo", "bar") on the request without header
+var errNoFoo error = µ.NoMatch{
+	Status: http.StatusBadRequest,
+	Source: "header",
+	Key:    "X-Foo",
+	Reason: "is missing",
+}
+
+func TestRoutesMethodAllowed(t *testing.T) {
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("foo")), µ.Header("X-Foo", "bar")),
+	).Endpoint()
+
+	req := mock.Input(mock.URL("/foo"))
+	it.Then(t).Should(
+		it.Equal(foo(req), errNoFoo),
+	)
+}
+
+func TestRoutesMethodUnknown(t *testing.T) {
+	for _, tt := range []struct {
+		route  µ.Routable
+		expect error
+	}{
+		{µ.ANY(µ.URI(µ.Path("foo")), µ.Header("X-Foo", "bar")), errNoFoo},
+		{µ.Route(µ.URI(µ.Path("foo")), µ.Method("GET")), µ.ErrNoMatch},
+	} {
+		foo := µ.NewRoutes(tt.route).Endpoint()
+		req := mock.Input(mock.Method("POST"), mock.URL("/foo"))
+
+		it.Then(t).Should(
+			it.Equal(foo(req), tt.expect),
+		)
+	}
+}
+
+func TestRoutesAutoOptions(t *testing.T) {
+	routes := []µ.Routable{
+		µ.GET(µ.URI(µ.Path("foo"))),
+		µ.POST(µ.URI(µ.Path("foo"))),
+		µ.HTTP("OPTIONS", µ.URI(µ.Path("bar")), mock.Output(http.StatusOK, "bar")),
+		µ.GET(µ.URI(µ.Path("bar"))),
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).Endpoint()
+		req := mock.Input(mock.Method("OPTIONS"), mock.URL("/foo"))
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(foo(req), http.StatusMethodNotAllowed)),
+		)
+	})
+
+	t.Run("Synthesized", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).With(µ.AutoOptions()).Endpoint()
+		req := mock.Input(mock.Method("OPTIONS"), mock.URL("/foo"))
+		err := foo(req)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusNoContent)),
+			it.Equal(err.(*µ.Output).GetHeader("Allow"), "GET, OPTIONS, POST"),
+		)
+	})
+
+	t.Run("Explicit", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).With(µ.AutoOptions()).Endpoint()
+		req := mock.Input(mock.Method("OPTIONS"), mock.URL("/bar"))
+		err := foo(req)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusOK)),
+			it.Nil(mock.CheckOutput(err, "bar")),
+		)
+	})
+}
+
+func TestRoutesAutoHead(t *testing.T) {
+	routes := []µ.Routable{
+		µ.GET(µ.URI(µ.Path("foo")), mock.Output(http.StatusOK, "foo")),
+		µ.HTTP("HEAD", µ.URI(µ.Path("bar")), mock.Output(http.StatusAccepted, "")),
+		µ.GET(µ.URI(µ.Path("bar")), mock.Output(http.StatusOK, "bar")),
+		µ.POST(µ.URI(µ.Path("baz"))),
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).Endpoint()
+		req := mock.Input(mock.Method("HEAD"), mock.URL("/foo"))
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(foo(req), http.StatusMethodNotAllowed)),
+		)
+	})
+
+	t.Run("Synthesized", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).With(µ.AutoHead()).Endpoint()
+		req := mock.Input(mock.Method("HEAD"), mock.URL("/foo"))
+		err := foo(req)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusOK)),
+			it.Equal(err.(*µ.Output).Body, ""),
+			it.Equal(err.(*µ.Output).GetHeader("Content-Length"), "3"),
+			it.Equal(req.Request.Method, "HEAD"),
+		)
+	})
+
+	t.Run("Explicit", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).With(µ.AutoHead()).Endpoint()
+		req := mock.Input(mock.Method("HEAD"), mock.URL("/bar"))
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(foo(req), http.StatusAccepted)),
+		)
+	})
+
+	t.Run("NoGet", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).With(µ.AutoHead()).Endpoint()
+		req := mock.Input(mock.Method("HEAD"), mock.URL("/baz"))
+		err := foo(req)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusMethodNotAllowed)),
+			it.Equal(err.(*µ.Output).GetHeader("Allow"), "POST"),
+		)
+	})
+}
+
+func TestRoutesIntrospection(t *testing.T) {
+	type myT struct {
+		ID   int
+		Path string
+	}
+	id, path := µ.Optics2[myT, int, string]()
+
+	routes := µ.NewRoutes(
+		µ.Annotate(
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(id))),
+			map[string]string{"summary": "user"},
+		),
+		µ.POST(µ.URI(µ.Path("users"))),
+		µ.ANY(µ.URI(µ.Path("static"), µ.PathAny(), µ.PathAll(path))),
+	).Routes()
+
+	it.Then(t).Should(
+		it.Seq(routes).Equal(
+			µ.RouteInfo{
+				Method: "_",
+				Path:   "/static/_/*Path",
+				Segments: []µ.SegmentInfo{
+					{Kind: µ.SegmentLiteral, Value: "static"},
+					{Kind: µ.SegmentAny},
+					{Kind: µ.SegmentAll, Struct: "gouldian_test.myT", Field: "Path", Type: "string"},
+				},
+				Policy: µ.RoutePolicy{TrailingSlash: "strict"},
+			},
+			µ.RouteInfo{
+				Method: "POST",
+				Path:   "/users",
+				Segments: []µ.SegmentInfo{
+					{Kind: µ.SegmentLiteral, Value: "users"},
+				},
+				Policy: µ.RoutePolicy{TrailingSlash: "strict"},
+			},
+			µ.RouteInfo{
+				Method: "GET",
+				Path:   "/users/:ID",
+				Segments: []µ.SegmentInfo{
+					{Kind: µ.SegmentLiteral, Value: "users"},
+					{Kind: µ.SegmentLens, Struct: "gouldian_test.myT", Field: "ID", Type: "int"},
+				},
+				Meta:   map[string]string{"summary": "user"},
+				Policy: µ.RoutePolicy{TrailingSlash: "strict"},
+			},
+		),
+	)
+}
+
+func TestRoutesBacktracking(t *testing.T) {
+	type myT struct{ ID, Path string }
+	id, path := µ.Optics2[myT, string, string]()
+
+	spec := [][]µ.Routable{
+		{
+			µ.GET(µ.URI(µ.Path("users"), µ.Path("me"), µ.Path("settings")), mock.Output(http.StatusOK, "settings")),
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(id), µ.Path("posts")), mock.Output(http.StatusOK, "posts")),
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(id)), mock.Output(http.StatusOK, "user")),
+			µ.GET(µ.URI(µ.Path(id)), mock.Output(http.StatusOK, "id")),
+			µ.GET(µ.URI(µ.Path("static"), µ.PathAll(path)), mock.Output(http.StatusOK, "static")),
+			µ.GET(µ.URI(µ.Path("static"), µ.Path("index.html")), mock.Output(http.StatusOK, "index")),
+		},
+		{
+			µ.GET(µ.URI(µ.Path("static"), µ.Path("index.html")), mock.Output(http.StatusOK, "index")),
+			µ.GET(µ.URI(µ.Path("static"), µ.PathAll(path)), mock.Output(http.StatusOK, "static")),
+			µ.GET(µ.URI(µ.Path(id)), mock.Output(http.StatusOK, "id")),
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(id)), mock.Output(http.StatusOK, "user")),
+			µ.GET(µ.URI(µ.Path("users"), µ.Path(id), µ.Path("posts")), mock.Output(http.StatusOK, "posts")),
+			µ.GET(µ.URI(µ.Path("users"), µ.Path("me"), µ.Path("settings")), mock.Output(http.StatusOK, "settings")),
+		},
+	}
+
+	for _, routes := range spec {
+		foo := µ.NewRoutes(routes...).Endpoint()
+
+		for url, expect := range map[string]string{
+			"/users/me/settings": "settings",
+			"/users/me/posts":    "posts",
+			"/users/me":          "user",
+			"/users/1/posts":     "posts",
+			"/users":             "id",
+			"/static/index.html": "index",
+			"/static/a/b":        "static",
+		} {
+			req := mock.Input(mock.URL(url))
+			it.Then(t).Should(
+				it.Nil(mock.CheckOutput(foo(req), expect)),
+			)
+		}
+
+		for _, url := range []string{"/", "/users/1/settings", "/static/"} {
+			req := mock.Input(mock.URL(url))
+			it.Then(t).Should(
+				it.Equal(foo(req), µ.ErrNotFound),
+			)
+		}
+	}
+}
+
+func TestRoutesBacktrackingValues(t *testing.T) {
+	type myT struct{ A, B string }
+	a, b := µ.Optics2[myT, string, string]("A", "B")
+
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path(a), µ.Path(b), µ.Path("x"))),
+		µ.GET(µ.URI(µ.Path(a), µ.PathAny(), µ.Path("y"))),
+	).Endpoint()
+
+	var val myT
+	req := mock.Input(mock.URL("/1/2/y"))
+	it.Then(t).Should(
+		it.Nil(foo(req)),
+		it.Nil(µ.FromContext(req, &val)),
+		it.Equal(val.A, "1"),
+		it.Equal(val.B, ""),
+	)
+}
+
+func TestRoutesConstraints(t *testing.T) {
+	type myT struct{ ID string }
+	id := µ.Optics1[myT, string]()
+
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("items"), µ.Path(id)), mock.Output(http.StatusOK, "any")),
+		µ.GET(µ.URI(µ.Path("items"), µ.Path(id, µ.IsInt())), mock.Output(http.StatusOK, "int")),
+		µ.GET(µ.URI(µ.Path("items"), µ.Path(id, µ.IsUUID())), mock.Output(http.StatusOK, "uuid")),
+		µ.GET(µ.URI(µ.Path("items"), µ.Path("new")), mock.Output(http.StatusOK, "new")),
+		µ.GET(µ.URI(µ.Path("kind"), µ.PathAny(µ.IsOneOf("a", "b"))), mock.Output(http.StatusOK, "enum")),
+		µ.GET(µ.URI(µ.Path("kind"), µ.Path(id, µ.IsRegexp("^[a-z]+$"))), mock.Output(http.StatusOK, "regexp")),
+	).Endpoint()
+
+	for url, expect := range map[string]string{
+		"/items/42":  "int",
+		"/items/-42": "int",
+		"/items/new": "new",
+		"/items/abc": "any",
+		"/items/6ba7b810-9dad-11d1-80b4-00c04fd430c8": "uuid",
+		"/kind/a": "enum",
+		"/kind/c": "regexp",
+	} {
+		req := mock.Input(mock.URL(url))
+		it.Then(t).Should(
+			it.Nil(mock.CheckOutput(foo(req), expect)),
+		)
+	}
+
+	var val myT
+	req := mock.Input(mock.URL("/items/42"))
+	it.Then(t).Should(
+		it.Nil(mock.CheckOutput(foo(req), "int")),
+		it.Nil(µ.FromContext(req, &val)),
+		it.Equal(val.ID, "42"),
+	)
+}
+
+func TestRoutesConstraintsInvalid(t *testing.T) {
+	type myT struct{ ID string }
+	id := µ.Optics1[myT, string]()
+	short := func(s string) bool { return len(s) < 3 }
+	empty := func(s string) bool { return s == "" }
+
+	for name, f := range map[string]func(){
+		"NoName": func() {
+			µ.Path(id, µ.Constraint{Test: short})
+		},
+		"NoTest": func() {
+			µ.PathAny(µ.Constraint{Name: "short"})
+		},
+		"SameName": func() {
+			µ.NewRoutes(
+				µ.GET(µ.URI(µ.Path("a"), µ.Path(id, µ.Constraint{Name: "short", Test: short}))),
+				µ.GET(µ.URI(µ.Path("a"), µ.Path(id, µ.Constraint{Name: "short", Test: empty}))),
+			)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				it.Then(t).ShouldNot(
+					it.Nil(recover()),
+				)
+			}()
+
+			f()
+			t.Error("constraint is accepted")
+		})
+	}
+}
+
+func TestRoutesConstraintsShared(t *testing.T) {
+	type myT struct{ ID string }
+	id := µ.Optics1[myT, string]()
+
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("a"), µ.Path(id, µ.IsRegexp("^[a-z]+$"))), mock.Output(http.StatusOK, "get")),
+		µ.POST(µ.URI(µ.Path("a"), µ.Path(id, µ.IsRegexp("^[a-z]+$"))), mock.Output(http.StatusOK, "post")),
+		µ.GET(µ.URI(µ.Path("a"), µ.Path(id, µ.IsInt(), µ.IsOneOf("1", "2"))), mock.Output(http.StatusOK, "int")),
+		µ.POST(µ.URI(µ.Path("a"), µ.Path(id, µ.IsInt(), µ.IsOneOf("1", "2"))), mock.Output(http.StatusOK, "int")),
+	).Endpoint()
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckOutput(foo(mock.Input(mock.Method("GET"), mock.URL("/a/abc"))), "get")),
+		it.Nil(mock.CheckOutput(foo(mock.Input(mock.Method("POST"), mock.URL("/a/abc"))), "post")),
+		it.Nil(mock.CheckOutput(foo(mock.Input(mock.Method("POST"), mock.URL("/a/1"))), "int")),
+	)
+}
+
+func TestRoutesConstraintViolation(t *testing.T) {
+	type myT struct{ ID int }
+	id := µ.Optics1[myT, int]()
+
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("items"), µ.Path(id, µ.IsInt()), µ.Path("tags"))),
+		µ.GET(µ.URI(µ.Path("items"), µ.Path("new"))),
+	).Endpoint()
+
+	t.Run("Constraint", func(t *testing.T) {
+		req := mock.Input(mock.URL("/items/abc/tags"))
+		err := foo(req)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusNotFound)),
+			it.String(err.(*µ.Output).Body).Contain(`path segment \"abc\" violates constraint int`),
+		)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		req := mock.Input(mock.URL("/items/abc/tag"))
+		it.Then(t).Should(
+			it.Equal(foo(req), µ.ErrNotFound),
+		)
+	})
+}
+
+func TestRoutesPattern(t *testing.T) {
+	type myT struct{ Name, Ext string }
+	name, ext := µ.Optics2[myT, string, string]("Name", "Ext")
+
+	routes := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("files"), µ.PathPattern(":.:", name, ext))),
+		µ.GET(µ.URI(µ.Path("files"), µ.Path(name)), mock.Output(http.StatusOK, "file")),
+	)
+	foo := routes.Endpoint()
+
+	t.Run("Match", func(t *testing.T) {
+		var val myT
+		req := mock.Input(mock.URL("/files/report.v2.pdf"))
+		it.Then(t).Should(
+			it.Nil(foo(req)),
+			it.Nil(µ.FromContext(req, &val)),
+			it.Equal(val.Name, "report.v2"),
+			it.Equal(val.Ext, "pdf"),
+		)
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		req := mock.Input(mock.URL("/files/report"))
+		it.Then(t).Should(
+			it.Nil(mock.CheckOutput(foo(req), "file")),
+		)
+	})
+
+	t.Run("Introspection", func(t *testing.T) {
+		it.Then(t).Should(
+			it.Equal(routes.Routes()[0].Path, "/files/:Name"),
+			it.Equal(routes.Routes()[1].Path, "/files/:Name.:Ext"),
+		)
+	})
+}
+
+func TestRoutesTrailingSlash(t *testing.T) {
+	routes := []µ.Routable{
+		µ.GET(µ.URI(µ.Path("users")), mock.Output(http.StatusOK, "users")),
+		µ.GET(µ.URI(µ.Path("static"), µ.Path("")), mock.Output(http.StatusOK, "static")),
+	}
+
+	t.Run("Strict", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).Endpoint()
+		for _, url := range []string{"/users/", "/static"} {
+			it.Then(t).Should(
+				it.Equal(foo(mock.Input(mock.URL(url))), µ.ErrNotFound),
+			)
+		}
+	})
+
+	t.Run("Ignore", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).With(µ.TrailingSlash(µ.SlashIgnore)).Endpoint()
+		for url, expect := range map[string]string{
+			"/users":   "users",
+			"/users/":  "users",
+			"/static":  "static",
+			"/static/": "static",
+		} {
+			it.Then(t).Should(
+				it.Nil(mock.CheckOutput(foo(mock.Input(mock.URL(url))), expect)),
+			)
+		}
+	})
+
+	t.Run("IgnoreVerb", func(t *testing.T) {
+		foo := µ.NewRoutes(
+			µ.GET(µ.URI(µ.Path("users")), mock.Output(http.StatusOK, "get")),
+			µ.POST(µ.URI(µ.Path("users"), µ.Path("")), mock.Output(http.StatusOK, "post")),
+		).With(µ.TrailingSlash(µ.SlashIgnore)).Endpoint()
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckOutput(foo(mock.Input(mock.Method("POST"), mock.URL("/users"))), "post")),
+			it.Nil(mock.CheckOutput(foo(mock.Input(mock.Method("GET"), mock.URL("/users/"))), "get")),
+			it.Nil(mock.CheckOutput(foo(mock.Input(mock.Method("GET"), mock.URL("/users"))), "get")),
+			it.Nil(mock.CheckOutput(foo(mock.Input(mock.Method("POST"), mock.URL("/users/"))), "post")),
+			it.Nil(mock.CheckStatusCode(foo(mock.Input(mock.Method("PUT"), mock.URL("/users"))), http.StatusMethodNotAllowed)),
+		)
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		for policy, status := range map[µ.SlashPolicy]int{
+			µ.SlashRedirect301: http.StatusMovedPermanently,
+			µ.SlashRedirect308: http.StatusPermanentRedirect,
+		} {
+			foo := µ.NewRoutes(routes...).With(µ.TrailingSlash(policy), µ.CollapseSlashes()).Endpoint()
+			err := foo(mock.Input(mock.URL("/users//?a=b")))
+
+			it.Then(t).Should(
+				it.Nil(mock.CheckStatusCode(err, status)),
+				it.Equal(err.(*µ.Output).GetHeader("Location"), "/users?a=b"),
+				it.Nil(mock.CheckOutput(foo(mock.Input(mock.URL("/users"))), "users")),
+			)
+		}
+	})
+}
+
+func TestRoutesCollapseSlashes(t *testing.T) {
+	type myT struct{ ID string }
+	id := µ.Optics1[myT, string]()
+
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("users"), µ.Path(id))),
+	).With(µ.CollapseSlashes()).Endpoint()
+
+	var val myT
+	req := mock.Input(mock.URL("/users///1"))
+	it.Then(t).Should(
+		it.Nil(foo(req)),
+		it.Nil(µ.FromContext(req, &val)),
+		it.Equal(val.ID, "1"),
+	)
+}
+
+func TestRoutesCaseInsensitive(t *testing.T) {
+	type myT struct{ ID string }
+	id := µ.Optics1[myT, string]()
+
+	routes := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("users"), µ.Path(id), µ.Path("Posts"))),
+	).With(µ.CaseInsensitive())
+	foo := routes.Endpoint()
+
+	var val myT
+	req := mock.Input(mock.URL("/USERS/AbC/posts"))
+	it.Then(t).Should(
+		it.Nil(foo(req)),
+		it.Nil(µ.FromContext(req, &val)),
+		it.Equal(val.ID, "AbC"),
+		it.Equal(routes.Routes()[0].Policy, µ.RoutePolicy{TrailingSlash: "strict", CaseInsensitive: true}),
+	)
+}
+
+func TestRoutesRawPath(t *testing.T) {
+	type myT struct{ Bucket, Key string }
+	bucket, key := µ.Optics2[myT, string, string]("Bucket", "Key")
+
+	routes := []µ.Routable{
+		µ.GET(µ.URI(µ.Path("s3"), µ.Path(bucket), µ.Path(key))),
+		µ.GET(µ.URI(µ.Path("héllo"), µ.Path(key))),
+		µ.GET(µ.URI(µ.Path("h%rt"), µ.Path(key))),
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		foo := µ.NewRoutes(routes...).Endpoint()
+		req := mock.Input(mock.URL("/s3/b/a%2Fb%20c.txt"))
+		it.Then(t).Should(
+			it.Equal(foo(req), µ.ErrNotFound),
+			it.Nil(foo(mock.Input(mock.URL("/h%25rt/k")))),
+		)
+	})
+
+	foo := µ.NewRoutes(routes...).With(µ.RawPath()).Endpoint()
+	for url, expect := range map[string]myT{
+		"/s3/b/a%2Fb%20c.txt": {Bucket: "b", Key: "a/b c.txt"},
+		"/s3/b%2F/100%25":     {Bucket: "b/", Key: "100%"},
+		"/s3/b/k":             {Bucket: "b", Key: "k"},
+		"/h%C3%A9llo/a%2Fb":   {Key: "a/b"},
+		"/h%25rt/a%2Fb":       {Key: "a/b"},
+	} {
+		var val myT
+		req := mock.Input(mock.URL(url))
+		it.Then(t).Should(
+			it.Nil(foo(req)),
+			it.Nil(µ.FromContext(req, &val)),
+			it.Equal(val, expect),
+		)
+	}
+
+	t.Run("EscapedLiteral", func(t *testing.T) {
+		req := mock.Input(mock.URL("/h%2525rt/k"))
+		it.Then(t).Should(
+			it.Equal(foo(req), µ.ErrNotFound),
+		)
+	})
+}
+
+func TestRoutesRawPathRedirect(t *testing.T) {
+	type myT struct{ Key string }
+	key := µ.Optics1[myT, string]()
+
+	foo := µ.NewRoutes(
+		µ.GET(µ.URI(µ.Path("s3"), µ.Path(key))),
+	).With(µ.RawPath(), µ.TrailingSlash(µ.SlashRedirect308)).Endpoint()
+
+	err := foo(mock.Input(mock.URL("/s3/a%2Fb/")))
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(err, http.StatusPermanentRedirect)),
+		it.Equal(err.(*µ.Output).GetHeader("Location"), "/s3/a%2Fb"),
+	)
+}
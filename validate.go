@@ -0,0 +1,308 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Validator is implemented by types that validate itself. The validation is
+executed by FMap and Map after the request is lifted to the type. Return
+ValidationError to report invalid fields.
+
+	func (req MyT) Validate() error {
+		if req.From > req.To {
+			return µ.ValidationError{{Name: "from", Reason: "must not exceed to"}}
+		}
+		return nil
+	}
+*/
+type Validator interface {
+	Validate() error
+}
+
+// InvalidParam is the invalid field of request, RFC 7807 extension member
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError is the list of invalid fields of request
+type ValidationError []InvalidParam
+
+func (err ValidationError) Error() string {
+	seq := make([]string, len(err))
+	for i, param := range err {
+		seq[i] = param.Name + " " + param.Reason
+	}
+	return "invalid params: " + strings.Join(seq, ", ")
+}
+
+/*
+validator of type, it is built from `validate` tags of struct fields.
+The tag is comma separated list of rules:
+
+	required      value is not zero
+	min=N         number is at least N, length of string, slice or map is at least N
+	max=N         number is at most N, length of string, slice or map is at most N
+	len=N         length of string, slice or map is N
+	oneof=a b c   value is one of listed
+	pattern=re    string matches regular expression, the rule is the last one
+
+Rules are not applied to nil pointers unless it is required. Nested
+structs are validated recursively.
+*/
+type validator []fieldValidator
+
+type fieldValidator struct {
+	index  int
+	name   string
+	rules  []rule
+	nested validator
+}
+
+// rule returns the reason of failure, empty string if value is valid
+type rule func(reflect.Value) string
+
+// validatorOf builds validator of type, it panics if rules are not valid
+func validatorOf[A any]() validator {
+	return newValidator(reflect.TypeOf(new(A)).Elem(), map[reflect.Type]bool{})
+}
+
+func newValidator(t reflect.Type, seen map[reflect.Type]bool) validator {
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	seq := validator{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		field := fieldValidator{index: i, name: fieldName(f)}
+		if tag, has := f.Tag.Lookup("validate"); has {
+			field.rules = newRules(t, f, tag)
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft != reflect.TypeOf(time.Time{}) {
+			field.nested = newValidator(ft, seen)
+		}
+
+		if len(field.rules) != 0 || len(field.nested) != 0 {
+			seq = append(seq, field)
+		}
+	}
+
+	return seq
+}
+
+// fieldName is the name of field as it is known to client: the name of
+// binding (see Bind), json or form tag, the name of field otherwise
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"path", "query", "header", "jwt", "json", "form"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" && name != "*" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+func newRules(t reflect.Type, f reflect.StructField, tag string) []rule {
+	seq := []rule{}
+	for tag != "" {
+		var spec string
+		if strings.HasPrefix(tag, "pattern=") {
+			spec, tag = tag, ""
+		} else {
+			spec, tag, _ = strings.Cut(tag, ",")
+		}
+
+		r, err := newRule(spec)
+		if err != nil {
+			panic(fmt.Errorf("invalid rule of %s.%s: %w", t.Name(), f.Name, err))
+		}
+		seq = append(seq, r)
+	}
+	return seq
+}
+
+func newRule(spec string) (rule, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(spec), "=")
+	switch name {
+	case "required":
+		return func(v reflect.Value) string {
+			if v.IsZero() {
+				return "is required"
+			}
+			return ""
+		}, nil
+	case "min", "max", "len":
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s requires number: %w", name, err)
+		}
+		return boundRule(name, n), nil
+	case "oneof":
+		seq := strings.Fields(arg)
+		return func(v reflect.Value) string {
+			v, ok := indirect(v)
+			if !ok {
+				return ""
+			}
+			s := fmt.Sprint(v.Interface())
+			for _, x := range seq {
+				if x == s {
+					return ""
+				}
+			}
+			return "must be one of " + arg
+		}, nil
+	case "pattern":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) string {
+			v, ok := indirect(v)
+			if !ok || v.Kind() != reflect.String || re.MatchString(v.String()) {
+				return ""
+			}
+			return "must match " + arg
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown rule %s", name)
+	}
+}
+
+func boundRule(name string, n float64) rule {
+	arg := strconv.FormatFloat(n, 'f', -1, 64)
+	return func(v reflect.Value) string {
+		v, ok := indirect(v)
+		if !ok {
+			return ""
+		}
+
+		var x float64
+		var what string
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			x = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			x = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			x = v.Float()
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+			x, what = float64(v.Len()), "length "
+		default:
+			return ""
+		}
+
+		switch {
+		case name == "min" && x < n:
+			return what + "must be at least " + arg
+		case name == "max" && x > n:
+			return what + "must be at most " + arg
+		case name == "len" && x != n:
+			return "length must be " + arg
+		default:
+			return ""
+		}
+	}
+}
+
+// indirect dereferences pointer, false if pointer is nil
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+func (seq validator) validate(v reflect.Value, prefix string, issues ValidationError) ValidationError {
+	for _, field := range seq {
+		fv := v.Field(field.index)
+		for _, check := range field.rules {
+			if reason := check(fv); reason != "" {
+				issues = append(issues, InvalidParam{Name: prefix + field.name, Reason: reason})
+				break
+			}
+		}
+
+		if len(field.nested) != 0 {
+			if nv, ok := indirect(fv); ok {
+				issues = field.nested.validate(nv, prefix+field.name+".", issues)
+			}
+		}
+	}
+	return issues
+}
+
+/*
+check validates the value with rules of struct tags and Validator interface.
+It returns Output 422 Unprocessable Entity if the value is not valid.
+*/
+func (seq validator) check(val any) error {
+	var issues ValidationError
+	if len(seq) != 0 {
+		issues = seq.validate(reflect.ValueOf(val).Elem(), "", nil)
+	}
+
+	var err error
+	if len(issues) == 0 {
+		if v, ok := val.(Validator); ok {
+			err = v.Validate()
+		}
+		if err == nil {
+			return nil
+		}
+		if invalid, ok := err.(ValidationError); ok {
+			issues = invalid
+		}
+	} else {
+		err = issues
+	}
+
+	issue := NewIssue(http.StatusUnprocessableEntity)
+	issue.InvalidParams = issues
+	if len(issues) == 0 {
+		issue.Detail = err.Error()
+	}
+
+	out := NewOutput(http.StatusUnprocessableEntity)
+	out.setIssue(issue, err)
+	return out
+}
@@ -0,0 +1,213 @@
+/*
+
+  Copyright 2019 Dmitry Kolesnikov, All Rights Reserved
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gouldian_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	µ "github.com/fogfish/gouldian/v2"
+	"github.com/fogfish/gouldian/v2/mock"
+	"github.com/fogfish/it/v2"
+)
+
+type validateT struct {
+	Name  string  `json:"name" validate:"required,max=5"`
+	Limit int     `validate:"min=1,max=100"`
+	Sort  *string `validate:"oneof=asc desc"`
+	Code  string  `validate:"pattern=^[a-z]{2,3}$"`
+}
+
+func (t validateT) Validate() error {
+	if t.Name == "admin" {
+		return µ.ValidationError{{Name: "name", Reason: "is reserved"}}
+	}
+	if t.Name == "root" {
+		return errors.New("root is not allowed")
+	}
+	return nil
+}
+
+func validateIssue(t *testing.T, err error) µ.Issue {
+	t.Helper()
+
+	var issue µ.Issue
+	if out, ok := err.(*µ.Output); ok {
+		if err := json.Unmarshal([]byte(out.Body), &issue); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return issue
+}
+
+func TestValidate(t *testing.T) {
+	name, limit, sort, code := µ.Optics4[validateT, string, int, *string, string]("Name", "Limit", "Sort", "Code")
+	foo := mock.Endpoint(
+		µ.GET(
+			µ.URI(µ.Path("foo"), µ.Path(name)),
+			µ.Param("limit", limit),
+			µ.ParamMaybe("sort", sort),
+			µ.Param("code", code),
+			µ.FMap(func(ctx *µ.Context, t *validateT) error {
+				return mock.Output(http.StatusOK, t.Name)(ctx)
+			}),
+		),
+	)
+
+	t.Run("Valid", func(t *testing.T) {
+		err := foo(mock.Input(mock.URL("/foo/joe?limit=10&sort=asc&code=en")))
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusOK)),
+		)
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		err := foo(mock.Input(mock.URL("/foo/joedoe?limit=0&sort=up&code=eng1")))
+		issue := validateIssue(t, err)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusUnprocessableEntity)),
+			it.Equal(issue.Status, http.StatusUnprocessableEntity),
+			it.Seq(issue.InvalidParams).Equal(
+				µ.InvalidParam{Name: "name", Reason: "length must be at most 5"},
+				µ.InvalidParam{Name: "Limit", Reason: "must be at least 1"},
+				µ.InvalidParam{Name: "Sort", Reason: "must be one of asc desc"},
+				µ.InvalidParam{Name: "Code", Reason: "must match ^[a-z]{2,3}$"},
+			),
+		)
+	})
+
+	t.Run("Decode", func(t *testing.T) {
+		err := foo(mock.Input(mock.URL("/foo/joe?limit=abc&code=en")))
+		nomatch, ok := err.(µ.NoMatch)
+		issue := validateIssue(t, nomatch.Output())
+
+		it.Then(t).Should(
+			it.True(ok),
+			it.Equal(issue.Status, http.StatusBadRequest),
+			it.Seq(issue.InvalidParams).Equal(
+				µ.InvalidParam{Name: "limit", Reason: "is not valid"},
+			),
+		)
+	})
+
+	t.Run("Validator", func(t *testing.T) {
+		err := foo(mock.Input(mock.URL("/foo/admin?limit=10&code=en")))
+		issue := validateIssue(t, err)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusUnprocessableEntity)),
+			it.Seq(issue.InvalidParams).Equal(
+				µ.InvalidParam{Name: "name", Reason: "is reserved"},
+			),
+		)
+	})
+
+	t.Run("ValidatorError", func(t *testing.T) {
+		err := foo(mock.Input(mock.URL("/foo/root?limit=10&code=en")))
+		issue := validateIssue(t, err)
+
+		it.Then(t).Should(
+			it.Nil(mock.CheckStatusCode(err, http.StatusUnprocessableEntity)),
+			it.Equal(issue.Detail, "root is not allowed"),
+			it.Seq(issue.InvalidParams).BeEmpty(),
+		)
+	})
+}
+
+func TestValidateNested(t *testing.T) {
+	type Item struct {
+		ID    string   `json:"id" validate:"required"`
+		Tags  []string `json:"tags" validate:"max=2"`
+		Price float64  `json:"price" validate:"min=0.01"`
+	}
+	type T struct {
+		Item Item `json:"item"`
+	}
+	item := µ.Optics1[T, Item]()
+
+	foo := mock.Endpoint(
+		µ.POST(
+			µ.URI(µ.Path("foo")),
+			µ.Body(item),
+			µ.Map(func(ctx *µ.Context, t *T) (*Item, error) { return &t.Item, nil }),
+		),
+	)
+
+	err := foo(mock.Input(mock.Method("POST"), mock.URL("/foo"),
+		mock.JSON(Item{Tags: []string{"a", "b", "c"}, Price: 0}),
+	))
+	issue := validateIssue(t, err)
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(err, http.StatusUnprocessableEntity)),
+		it.Seq(issue.InvalidParams).Equal(
+			µ.InvalidParam{Name: "item.id", Reason: "is required"},
+			µ.InvalidParam{Name: "item.tags", Reason: "length must be at most 2"},
+			µ.InvalidParam{Name: "item.price", Reason: "must be at least 0.01"},
+		),
+	)
+}
+
+func TestValidateBinding(t *testing.T) {
+	type T struct {
+		ID    string `path:"id" json:"ident" validate:"max=3"`
+		Limit int    `query:"limit" validate:"min=1"`
+	}
+	req := µ.Bind[T]()
+
+	foo := mock.Endpoint(
+		µ.GET(
+			req.URI("/foo/:id"),
+			req.Endpoint(),
+			µ.FMap(func(ctx *µ.Context, t *T) error { return nil }),
+		),
+	)
+
+	err := foo(mock.Input(mock.URL("/foo/abcd?limit=0")))
+	issue := validateIssue(t, err)
+
+	it.Then(t).Should(
+		it.Nil(mock.CheckStatusCode(err, http.StatusUnprocessableEntity)),
+		it.Seq(issue.InvalidParams).Equal(
+			µ.InvalidParam{Name: "id", Reason: "length must be at most 3"},
+			µ.InvalidParam{Name: "limit", Reason: "must be at least 1"},
+		),
+		it.Equal(
+			foo(mock.Input(mock.URL("/foo/abc?limit=abc"))),
+			error(µ.NoMatch{Status: 400, Source: "param", Key: "limit", Reason: "is not valid"}),
+		),
+	)
+}
+
+func TestValidateInvalidRule(t *testing.T) {
+	type T struct {
+		A string `validate:"unknown"`
+	}
+
+	defer func() {
+		it.Then(t).ShouldNot(
+			it.Nil(recover()),
+		)
+	}()
+
+	µ.FMap(func(*µ.Context, *T) error { return nil })
+}